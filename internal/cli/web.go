@@ -3,6 +3,7 @@ package cli
 import (
 	"collectify/internal/config"
 	"collectify/internal/router"
+	"collectify/internal/service"
 	"context"
 	"fmt"
 	"log"
@@ -33,6 +34,12 @@ func DoWeb() {
 		Handler: r,
 	}
 
+	// 启动回收站自动清理
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	retention := time.Duration(cfg.RecycleBin.RetentionDay) * time.Hour * 24
+	go service.RunRecycleBinJanitor(janitorCtx, retention, cfg.RecycleBin.PurgeInterval)
+
 	// 启动服务器
 	go func() {
 		log.Printf("🚀 服务已启动：http://localhost:%d\n", cfg.Server.Port)
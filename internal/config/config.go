@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	_ "github.com/joho/godotenv/autoload"
@@ -27,19 +28,38 @@ type ConfigDatabase struct {
 
 // 服务器配置
 type ConfigServer struct {
-	Port int    `env:"SERVER_PORT" envDefault:"8080"`
-	Mode string `env:"SERVER_MODE" envDefault:"release"`
+	Port           int      `env:"SERVER_PORT" envDefault:"8080"`
+	Mode           string   `env:"SERVER_MODE" envDefault:"release"`
+	TrustedProxies []string `env:"SERVER_TRUSTED_PROXIES" envSeparator:","` // 信任的反向代理地址，仅信任其转发的客户端 IP
 }
 
 // 回收站配置
 type ConfigRecycleBin struct {
-	Enable bool `env:"RECYCLEBIN_ENABLE" envDefault:"false"`
+	Enable        bool          `env:"RECYCLEBIN_ENABLE" envDefault:"false"`
+	RetentionDay  int           `env:"RECYCLEBIN_RETENTION_DAY" envDefault:"0"`   // 删除记录保留天数，0 表示永久保留，不自动清理
+	PurgeInterval time.Duration `env:"RECYCLEBIN_PURGE_INTERVAL" envDefault:"1h"` // 自动清理的检查间隔
 }
 
 type ConfigAuth struct {
-	Enable    bool   `env:"AUTH_ENABLE" envDefault:"false"`
-	JwtSecret string `env:"AUTH_JWT_SECRET"`
-	ExpireDay int    `env:"AUTH_EXPIRE_DAY" envDefault:"15"`
+	Enable         bool          `env:"AUTH_ENABLE" envDefault:"false"`
+	JwtSecret      string        `env:"AUTH_JWT_SECRET"`
+	ExpireDay      int           `env:"AUTH_EXPIRE_DAY" envDefault:"15"`        // 登录会话和刷新令牌的有效期，0 表示永不过期
+	AccessTokenTTL time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" envDefault:"15m"` // 与刷新令牌配对签发的访问令牌有效期
+	Private        bool          `env:"AUTH_PRIVATE" envDefault:"false"`        // 私有实例，浏览和搜索也需要登录
+	PublicLibrary  string        `env:"AUTH_PUBLIC_LIBRARY"`                    // 匿名访客浏览的藏品库所属的用户名，为空时为最早创建的管理员
+
+	LoginMaxAttempts      int           `env:"AUTH_LOGIN_MAX_ATTEMPTS" envDefault:"5"`         // 同一用户名连续登录失败多少次后锁定，0 表示不限制
+	LoginMaxAttemptsPerIP int           `env:"AUTH_LOGIN_MAX_ATTEMPTS_PER_IP" envDefault:"20"` // 同一 IP 连续登录失败多少次后锁定，0 表示不限制
+	LoginLockout          time.Duration `env:"AUTH_LOGIN_LOCKOUT" envDefault:"1m"`             // 首次锁定时长，此后每次失败翻倍
+
+	OIDCIssuer        string   `env:"AUTH_OIDC_ISSUER"`                                                           // OpenID Connect 提供方地址，为空时不启用
+	OIDCClientID      string   `env:"AUTH_OIDC_CLIENT_ID"`                                                        // 客户端 ID
+	OIDCClientSecret  string   `env:"AUTH_OIDC_CLIENT_SECRET"`                                                    // 客户端密钥
+	OIDCRedirectURL   string   `env:"AUTH_OIDC_REDIRECT_URL"`                                                     // 前端回调地址，如 https://example.com/oidc/callback
+	OIDCScopes        []string `env:"AUTH_OIDC_SCOPES" envSeparator:"," envDefault:"openid,profile,email,groups"` // 请求的 scope
+	OIDCGroupsClaim   string   `env:"AUTH_OIDC_GROUPS_CLAIM" envDefault:"groups"`                                 // 用户组所在的 claim
+	OIDCAllowedGroups []string `env:"AUTH_OIDC_ALLOWED_GROUPS" envSeparator:","`                                  // 允许登录的用户组，为空时不限制
+	OIDCDefaultRole   int      `env:"AUTH_OIDC_DEFAULT_ROLE" envDefault:"2"`                                      // 首次登录时自动创建的用户的角色
 }
 
 var config = &Config{}
@@ -56,7 +76,7 @@ func InitConfig() (*Config, error) {
 	}
 
 	config = &cfg
-	log.Println("Config loaded:", config)
+	log.Println("Config loaded:", config.Redacted())
 
 	// 如果启用认证，且未设置 JWT 密钥
 	if config.Auth.Enable && config.Auth.JwtSecret == "" {
@@ -74,6 +94,17 @@ func InitConfig() (*Config, error) {
 	return config, nil
 }
 
+// Redacted 返回隐藏了密钥的配置副本，用于输出日志
+func (c Config) Redacted() Config {
+	if c.Auth.JwtSecret != "" {
+		c.Auth.JwtSecret = "******"
+	}
+	if c.Auth.OIDCClientSecret != "" {
+		c.Auth.OIDCClientSecret = "******"
+	}
+	return c
+}
+
 // GetConfig 获取配置
 func GetConfig() *Config {
 	return config
@@ -2,7 +2,9 @@ package conn
 
 import (
 	"collectify/internal/config"
+	"collectify/internal/dao"
 	model "collectify/internal/model/db"
+	"collectify/internal/pkg/password"
 	"fmt"
 
 	"github.com/glebarez/sqlite"
@@ -33,20 +35,46 @@ func InitDB(cfg *config.Config) error {
 		&model.Category{},
 		&model.Collection{},
 		&model.Field{},
+		&model.FieldOption{},
 		&model.Item{},
+		&model.ItemAlias{},
 		&model.Tag{},
 		&model.ItemFieldValue{},
 		&model.User{},
+		&model.APIToken{},
+		&model.Session{},
+		&model.LoginAudit{},
+		&model.UserIdentity{},
 	)
 	if err != nil {
 		return err
 	}
 
+	err = dropLegacyIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = dao.InitItemIndex(db)
+	if err != nil {
+		return err
+	}
+
+	err = dao.InitNameKeys(db)
+	if err != nil {
+		return err
+	}
+
 	err = initAdminUser()
 	if err != nil {
 		return err
 	}
 
+	err = assignUnownedData()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -54,6 +82,33 @@ func GetDB() *gorm.DB {
 	return db
 }
 
+// 删除旧版本的唯一索引
+// 旧索引未排除软删除记录，已删除的记录会阻止创建同名记录，已由仅约束未删除记录的部分索引替代
+// 支持多用户后，名称仅在同一所有者下唯一
+func dropLegacyIndexes() error {
+	legacyIndexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{&model.Category{}, "idx_categories_name"},
+		{&model.Tag{}, "idx_tags_name"},
+		{&model.Field{}, "idx_field_category_name"},
+		{&model.Category{}, "idx_category_name_live"},
+		{&model.Tag{}, "idx_tag_name_live"},
+	}
+
+	migrator := db.Migrator()
+	for _, index := range legacyIndexes {
+		if !migrator.HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // 当用户表为空时，创建一个管理员用户
 func initAdminUser() error {
 	var count int64
@@ -65,11 +120,42 @@ func initAdminUser() error {
 		return nil
 	}
 
+	hash, err := password.Hash(model.DefaultAdminPassword)
+	if err != nil {
+		return err
+	}
+
 	user := &model.User{
-		Username: "admin",
-		Password: "admin",
-		Role:     model.UserRoleAdmin,
+		Username:           model.DefaultAdminUsername,
+		Password:           hash,
+		Role:               model.UserRoleAdmin,
+		MustChangePassword: true,
 	}
 
 	return db.Create(user).Error
 }
+
+// 将没有所有者的数据归属到第一个管理员
+// 包括支持多用户前的旧数据，以及未启用认证时创建的数据
+func assignUnownedData() error {
+	var admin model.User
+	err := db.Where("role = ?", model.UserRoleAdmin).Order("id").First(&admin).Error
+	if err != nil {
+		return err
+	}
+
+	models := []interface{}{
+		&model.Category{},
+		&model.Collection{},
+		&model.Field{},
+		&model.Item{},
+		&model.Tag{},
+	}
+	for _, m := range models {
+		err := db.Unscoped().Model(m).Where("user_id = 0 OR user_id IS NULL").UpdateColumn("user_id", admin.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
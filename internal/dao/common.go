@@ -3,10 +3,14 @@ package dao
 import (
 	common "collectify/internal/model/common"
 	model "collectify/internal/model/db"
+	"collectify/internal/model/define"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Filter struct {
@@ -15,24 +19,72 @@ type Filter struct {
 }
 
 type OrderBy struct {
-	Column string
-	Desc   bool
+	Column    string
+	Desc      bool
+	Args      []interface{} // Column 为带参数的表达式（如子查询）时的参数
+	NullsLast bool          // 空值排在最后，不论升序还是降序
 }
 
-type Join struct {
-	Table string
-	On    string
+// OwnedBy 将所有者加入查询条件
+func OwnedBy(uniqueFields map[string]interface{}, userID uint) map[string]interface{} {
+	uniqueFields["user_id"] = userID
+	return uniqueFields
+}
+
+// OwnerFilter 生成所有者筛选条件，table 用于联表查询时区分字段
+func OwnerFilter(table string, userID uint) []Filter {
+	column := "user_id"
+	if table != "" {
+		column = table + ".user_id"
+	}
+	return []Filter{
+		{
+			Where: column + " = ?",
+			Args:  []interface{}{userID},
+		},
+	}
+}
+
+// ItemNameFilter 按名称筛选收藏品，收藏品或任一别名满足条件即可，cond 中的 %s 为表名，如 "%s.name = ?"
+func ItemNameFilter(cond string, arg interface{}) Filter {
+	return Filter{
+		Where: "(" + fmt.Sprintf(cond, "items") +
+			" OR EXISTS (SELECT 1 FROM item_aliases WHERE item_aliases.item_id = items.id AND " + fmt.Sprintf(cond, "item_aliases") + "))",
+		Args: []interface{}{arg, arg},
+	}
+}
+
+// JoinTableFilter 按多对多关联表筛选收藏品，如 item_tags 的 tag_id，match 为 define.MatchAll 等匹配方式
+func JoinTableFilter(table, column string, ids []uint, match string) Filter {
+	subquery := fmt.Sprintf("SELECT 1 FROM %s WHERE %s.item_id = items.id AND %s.%s IN ?", table, table, table, column)
+	switch match {
+	case define.MatchAll:
+		// 关联了全部 ID，重复的 ID 只计一次
+		unique := make(map[uint]struct{}, len(ids))
+		for _, id := range ids {
+			unique[id] = struct{}{}
+		}
+		return Filter{
+			Where: fmt.Sprintf("(SELECT COUNT(DISTINCT %s.%s) FROM %s WHERE %s.item_id = items.id AND %s.%s IN ?) = ?", table, column, table, table, table, column),
+			Args:  []interface{}{ids, len(unique)},
+		}
+	case define.MatchNone:
+		return Filter{Where: "NOT EXISTS (" + subquery + ")", Args: []interface{}{ids}}
+	default:
+		return Filter{Where: "EXISTS (" + subquery + ")", Args: []interface{}{ids}}
+	}
 }
 
 // uniqueFields: 业务上需要检查唯一性的字段，如 name, email
 // filters: 查询时的附加条件，如排除当前记录、状态过滤等
+// 同时存在未删除和已删除的重复记录时，优先返回未删除的记录
 func DuplicateCheck[T model.GormModel](tx *gorm.DB, uniqueFields map[string]interface{}, filters []Filter) (id uint, isDeleted bool, err error) {
 	var t T
 	query := tx.Unscoped().Model(&t).Where(uniqueFields)
 	for _, filter := range filters {
 		query = query.Where(filter.Where, filter.Args...)
 	}
-	err = query.First(&t).Error
+	err = query.Order("deleted_at IS NOT NULL").Order("deleted_at DESC").First(&t).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return 0, false, nil
@@ -99,6 +151,28 @@ func DeleteByFilter[T model.GormModel](tx *gorm.DB, filters []Filter, isSoftDele
 	return query.Delete(&t).Error
 }
 
+// GetDeletedList 获取回收站中的记录，按删除时间逆序排序
+func GetDeletedList[T model.GormModel](tx *gorm.DB, filters []Filter) ([]T, error) {
+	var t []T
+	query := tx.Unscoped().Model(&t).Where("deleted_at IS NOT NULL")
+	for _, filter := range filters {
+		query = query.Where(filter.Where, filter.Args...)
+	}
+	if err := query.Order("deleted_at DESC").Find(&t).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteJoinRows 删除多对多关联表中的记录，关联表无软删除，总是硬删除
+func DeleteJoinRows(tx *gorm.DB, table string, filters []Filter) error {
+	query := tx.Table(table)
+	for _, filter := range filters {
+		query = query.Where(filter.Where, filter.Args...)
+	}
+	return query.Delete(map[string]interface{}{}).Error
+}
+
 func Get[T model.GormModel](tx *gorm.DB, uniqueFields map[string]interface{}, preloads ...string) (T, error) {
 	var t T
 	query := tx.Model(&t)
@@ -125,16 +199,7 @@ func GetList[T model.GormModel](tx *gorm.DB, filters []Filter, orderBy []OrderBy
 		return nil, 0, err
 	}
 
-	for _, orderBy := range orderBy {
-		var sort string
-		if orderBy.Desc {
-			sort = "DESC"
-		} else {
-			sort = "ASC"
-		}
-		query = query.Order(orderBy.Column + " " + sort)
-	}
-
+	query = orderQuery(query, orderBy)
 	if !p.Disable {
 		query = query.Offset(p.GetOffset()).Limit(p.GetLimit())
 	}
@@ -145,25 +210,88 @@ func GetList[T model.GormModel](tx *gorm.DB, filters []Filter, orderBy []OrderBy
 	return t, total, nil
 }
 
-func Pluck[T model.GormModel, R any](tx *gorm.DB, column string, joins []Join, filters []Filter, distinct bool) ([]R, error) {
+// GetPage 与 GetList 相同，但在同一个查询中完成排序、分页和计数（窗口函数），只取出当前页的 ID，再加载当前页的记录和关联表
+// 适合条件复杂、满足条件的记录很多的查询，不需要先取出所有满足条件的 ID
+func GetPage[T model.GormModel](tx *gorm.DB, filters []Filter, orderBy []OrderBy, p common.Pagination, preloads ...string) ([]T, int64, error) {
+	if p.Disable {
+		return GetList[T](tx, filters, orderBy, p, preloads...)
+	}
+
 	var t []T
-	var r []R
+	filtered := func() *gorm.DB {
+		query := tx.Model(&t)
+		for _, filter := range filters {
+			query = query.Where(filter.Where, filter.Args...)
+		}
+		return query
+	}
+
+	var rows []struct {
+		ID    uint
+		Total int64
+	}
+	err := orderQuery(filtered(), orderBy).
+		Select("id, COUNT(*) OVER () AS total").
+		Offset(p.GetOffset()).Limit(p.GetLimit()).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 页码超出范围时没有结果，无法从窗口函数得到总数
+	if len(rows) == 0 {
+		var total int64
+		if p.GetOffset() > 0 {
+			if err := filtered().Count(&total).Error; err != nil {
+				return nil, 0, err
+			}
+		}
+		return t, total, nil
+	}
+
+	ids := make([]uint, len(rows))
+	positions := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+		positions[row.ID] = i
+	}
+
 	query := tx.Model(&t)
-	for _, join := range joins {
-		joinStr := fmt.Sprintf("LEFT JOIN %s ON %s", join.Table, join.On)
-		query = query.Joins(joinStr)
+	for _, preload := range preloads {
+		query = query.Preload(preload)
 	}
-	for _, filter := range filters {
-		query = query.Where(filter.Where, filter.Args...)
+	if err := query.Where("id IN ?", ids).Find(&t).Error; err != nil {
+		return nil, 0, err
 	}
-	if distinct {
-		query = query.Distinct(column)
+	slices.SortFunc(t, func(a, b T) int {
+		return positions[a.GetID()] - positions[b.GetID()]
+	})
+	return t, rows[0].Total, nil
+}
+
+// orderQuery 添加排序子句
+func orderQuery(query *gorm.DB, orderBy []OrderBy) *gorm.DB {
+	// 排序表达式可能带参数，GORM 不会合并多个表达式形式的排序子句，拼接为一个子句
+	var sorts []string
+	var sortArgs []interface{}
+	for _, orderBy := range orderBy {
+		var sort string
+		if orderBy.Desc {
+			sort = "DESC"
+		} else {
+			sort = "ASC"
+		}
+		if orderBy.NullsLast {
+			sorts = append(sorts, orderBy.Column+" IS NULL")
+			sortArgs = append(sortArgs, orderBy.Args...)
+		}
+		sorts = append(sorts, orderBy.Column+" "+sort)
+		sortArgs = append(sortArgs, orderBy.Args...)
 	}
-	err := query.Pluck(column, &r).Error
-	if err != nil {
-		return nil, err
+	if len(sorts) == 0 {
+		return query
 	}
-	return r, nil
+	return query.Order(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sorts, ", "), Vars: sortArgs, WithoutParentheses: true}})
 }
 
 func Update[T model.GormModel](tx *gorm.DB, uniqueFields map[string]interface{}, updateFields map[string]interface{}) error {
@@ -171,14 +299,25 @@ func Update[T model.GormModel](tx *gorm.DB, uniqueFields map[string]interface{},
 	return tx.Model(&t).Where(uniqueFields).Updates(updateFields).Error
 }
 
-func Associate[T1 model.GormModel, T2 model.GormModel](tx *gorm.DB, id1, id2 uint, association string) error {
-	uniqueFields := map[string]interface{}{"id": id1}
+// UpdateByFilter 按条件更新记录，返回更新的行数，用于先检查后更新的操作
+func UpdateByFilter[T model.GormModel](tx *gorm.DB, filters []Filter, updateFields map[string]interface{}) (int64, error) {
+	var t T
+	query := tx.Model(&t)
+	for _, filter := range filters {
+		query = query.Where(filter.Where, filter.Args...)
+	}
+	result := query.Updates(updateFields)
+	return result.RowsAffected, result.Error
+}
+
+func Associate[T1 model.GormModel, T2 model.GormModel](tx *gorm.DB, id1, id2 uint, association string, userID uint) error {
+	uniqueFields := OwnedBy(map[string]interface{}{"id": id1}, userID)
 	t1, err := Get[T1](tx, uniqueFields)
 	if err != nil {
 		return err
 	}
 
-	uniqueFields = map[string]interface{}{"id": id2}
+	uniqueFields = OwnedBy(map[string]interface{}{"id": id2}, userID)
 	t2, err := Get[T2](tx, uniqueFields)
 	if err != nil {
 		return err
@@ -192,14 +331,14 @@ func Associate[T1 model.GormModel, T2 model.GormModel](tx *gorm.DB, id1, id2 uin
 	return nil
 }
 
-func Disassociate[T1 model.GormModel, T2 model.GormModel](tx *gorm.DB, id1, id2 uint, association string) error {
-	uniqueFields := map[string]interface{}{"id": id1}
+func Disassociate[T1 model.GormModel, T2 model.GormModel](tx *gorm.DB, id1, id2 uint, association string, userID uint) error {
+	uniqueFields := OwnedBy(map[string]interface{}{"id": id1}, userID)
 	t1, err := Get[T1](tx, uniqueFields)
 	if err != nil {
 		return err
 	}
 
-	uniqueFields = map[string]interface{}{"id": id2}
+	uniqueFields = OwnedBy(map[string]interface{}{"id": id2}, userID)
 	t2, err := Get[T2](tx, uniqueFields)
 	if err != nil {
 		return err
@@ -3,7 +3,9 @@ package dao
 import (
 	model "collectify/internal/model/db"
 	"collectify/internal/model/define"
+	"collectify/internal/pkg/timerange"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -54,6 +56,10 @@ func (c *FieldValueCreator) validateRequired() error {
 		if len(ints) == 0 {
 			return fmt.Errorf("field %s is required", c.field.Name)
 		}
+	case model.FieldTypeFloat, model.FieldTypeReference:
+		if c.value == nil || c.value == "" || len(toSlice(c.value)) == 0 {
+			return fmt.Errorf("field %s is required", c.field.Name)
+		}
 	case model.FieldTypeBool:
 		// bool 可能为 false，所以只检查是否为 nil
 		if c.value == nil {
@@ -64,6 +70,13 @@ func (c *FieldValueCreator) validateRequired() error {
 		if c.value == nil {
 			return fmt.Errorf("field %s is required", c.field.Name)
 		}
+	case model.FieldTypeSelect:
+		if c.value == nil || c.value == "" {
+			return fmt.Errorf("field %s is required", c.field.Name)
+		}
+		if values, ok := c.value.([]interface{}); ok && len(values) == 0 {
+			return fmt.Errorf("field %s is required", c.field.Name)
+		}
 	}
 	return nil
 }
@@ -84,15 +97,33 @@ func (c *FieldValueCreator) createSingleValue() error {
 	case model.FieldTypeInt:
 		i := cast.ToInt(c.value)
 		ifv.ValueInt = &i
+	case model.FieldTypeFloat:
+		f, err := c.toFloat(c.value)
+		if err != nil {
+			return err
+		}
+		ifv.ValueFloat = &f
+	case model.FieldTypeReference:
+		ref, err := c.resolveReference(c.value)
+		if err != nil {
+			return err
+		}
+		ifv.ValueItem = &ref
 	case model.FieldTypeBool:
 		b := cast.ToBool(c.value)
 		ifv.ValueBool = &b
 	case model.FieldTypeDatetime:
-		t, ok := c.value.(time.Time)
-		if !ok || t.IsZero() {
+		t, err := toTime(c.value)
+		if err != nil {
 			return fmt.Errorf("invalid datetime value for field %s", c.field.Name)
 		}
 		ifv.ValueTime = &t
+	case model.FieldTypeSelect:
+		option, err := resolveFieldOption(c.field, c.value)
+		if err != nil {
+			return err
+		}
+		ifv.ValueOption = &option.ID
 	default:
 		return fmt.Errorf("unsupported field type: %d", c.field.Type)
 	}
@@ -122,6 +153,40 @@ func (c *FieldValueCreator) createArrayValues() error {
 				ValueInt: &i,
 			})
 		}
+	case model.FieldTypeFloat:
+		for _, value := range toSlice(c.value) {
+			f, err := c.toFloat(value)
+			if err != nil {
+				return err
+			}
+			if err := c.createSingleValueWith(c.tx, &model.ItemFieldValue{
+				ItemID:     c.itemID,
+				FieldID:    c.field.ID,
+				ValueFloat: &f,
+			}); err != nil {
+				return err
+			}
+		}
+	case model.FieldTypeReference:
+		// 忽略重复关联的收藏品
+		referenced := make(map[uint]bool)
+		for _, value := range toSlice(c.value) {
+			ref, err := c.resolveReference(value)
+			if err != nil {
+				return err
+			}
+			if referenced[ref] {
+				continue
+			}
+			referenced[ref] = true
+			if err := c.createSingleValueWith(c.tx, &model.ItemFieldValue{
+				ItemID:    c.itemID,
+				FieldID:   c.field.ID,
+				ValueItem: &ref,
+			}); err != nil {
+				return err
+			}
+		}
 	case model.FieldTypeBool:
 		for _, b := range cast.ToBoolSlice(c.value) {
 			err = c.createSingleValueWith(c.tx, &model.ItemFieldValue{
@@ -131,15 +196,38 @@ func (c *FieldValueCreator) createArrayValues() error {
 			})
 		}
 	case model.FieldTypeDatetime:
-		for _, t := range c.value.([]time.Time) { // 注意类型断言
-			if t.IsZero() {
-				continue
+		for _, value := range toSlice(c.value) {
+			t, err := toTime(value)
+			if err != nil {
+				return fmt.Errorf("invalid datetime value for field %s", c.field.Name)
 			}
-			err = c.createSingleValueWith(c.tx, &model.ItemFieldValue{
+			if err := c.createSingleValueWith(c.tx, &model.ItemFieldValue{
 				ItemID:    c.itemID,
 				FieldID:   c.field.ID,
 				ValueTime: &t,
-			})
+			}); err != nil {
+				return err
+			}
+		}
+	case model.FieldTypeSelect:
+		// 忽略重复选择的选项
+		selected := make(map[uint]bool)
+		for _, value := range toSlice(c.value) {
+			option, err := resolveFieldOption(c.field, value)
+			if err != nil {
+				return err
+			}
+			if selected[option.ID] {
+				continue
+			}
+			selected[option.ID] = true
+			if err := c.createSingleValueWith(c.tx, &model.ItemFieldValue{
+				ItemID:      c.itemID,
+				FieldID:     c.field.ID,
+				ValueOption: &option.ID,
+			}); err != nil {
+				return err
+			}
 		}
 	default:
 		return fmt.Errorf("unsupported array field type: %d", c.field.Type)
@@ -147,6 +235,44 @@ func (c *FieldValueCreator) createArrayValues() error {
 	return err
 }
 
+// toFloat 将值转换为小数，并按字段精度舍入
+func (c *FieldValueCreator) toFloat(value interface{}) (float64, error) {
+	f, err := cast.ToFloat64E(value)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid float value %v for field %s", value, c.field.Name)
+	}
+	if c.field.Precision != nil {
+		pow := math.Pow10(*c.field.Precision)
+		f = math.Round(f*pow) / pow
+	}
+	return f, nil
+}
+
+// resolveReference 检查关联的收藏品存在、未删除且与当前收藏品属于同一用户，字段限定分类时还需属于该分类
+func (c *FieldValueCreator) resolveReference(value interface{}) (uint, error) {
+	id, err := cast.ToUintE(value)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid item %v for field %s", value, c.field.Name)
+	}
+	if id == c.itemID {
+		return 0, fmt.Errorf("item cannot reference itself in field %s", c.field.Name)
+	}
+
+	query := c.tx.Model(&model.Item{}).
+		Where("id = ? AND user_id = (SELECT user_id FROM items WHERE id = ?)", id, c.itemID)
+	if c.field.TargetCategoryID != nil {
+		query = query.Where("category_id = ?", *c.field.TargetCategoryID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("invalid item %d for field %s", id, c.field.Name)
+	}
+	return id, nil
+}
+
 // createSingleValueWith 用于数组场景，避免重复写 dao.Create
 func (c *FieldValueCreator) createSingleValueWith(tx *gorm.DB, ifv *model.ItemFieldValue) error {
 	return Create(tx, ifv)
@@ -156,6 +282,8 @@ type FieldValueQueryBuilder struct {
 	tx    *gorm.DB
 	field model.Field
 	value interface{}
+
+	itemLevel bool // 生成的条件作用于收藏品而不是关联的字段值行
 }
 
 func NewFieldValueQueryBuilder(tx *gorm.DB, field model.Field, value interface{}) *FieldValueQueryBuilder {
@@ -167,6 +295,11 @@ func NewFieldValueQueryBuilder(tx *gorm.DB, field model.Field, value interface{}
 }
 
 func (b *FieldValueQueryBuilder) Build() (Filter, error) {
+	// 带运算符的筛选条件适用于所有字段类型
+	if ff, ok := define.ParseFieldFilter(b.value); ok {
+		return b.buildOperator(ff)
+	}
+
 	var filter Filter
 
 	wheres := []string{
@@ -177,6 +310,24 @@ func (b *FieldValueQueryBuilder) Build() (Filter, error) {
 		b.field.ID,
 	}
 
+	// 关联字段按收藏品 ID 精确匹配，单个和多个关联处理方式相同
+	if b.field.Type == model.FieldTypeReference {
+		err := b.queryReferenceValues(&filter)
+		if err != nil {
+			return Filter{}, err
+		}
+		return filter, nil
+	}
+
+	// 选择字段按选项精确匹配，单选和多选处理方式相同
+	if b.field.Type == model.FieldTypeSelect {
+		err := b.queryOptionValues(&filter)
+		if err != nil {
+			return Filter{}, err
+		}
+		return filter, nil
+	}
+
 	if b.field.IsArray {
 		err := b.queryArrayValues(&filter)
 		if err != nil {
@@ -192,6 +343,19 @@ func (b *FieldValueQueryBuilder) Build() (Filter, error) {
 	return filter, nil
 }
 
+// BuildExists 生成不依赖 item_field_values 关联的条件，作用于关联行的条件改写为 EXISTS 子查询，可以与其他条件任意组合
+func (b *FieldValueQueryBuilder) BuildExists() (Filter, error) {
+	filter, err := b.Build()
+	if err != nil || b.itemLevel {
+		return filter, err
+	}
+	// 子查询中的 item_field_values 指向子查询自身的表
+	return Filter{
+		Where: "EXISTS (SELECT 1 FROM item_field_values WHERE item_field_values.item_id = items.id AND item_field_values.deleted_at IS NULL AND " + filter.Where + ")",
+		Args:  filter.Args,
+	}, nil
+}
+
 func (b *FieldValueQueryBuilder) querySingleValue(filter *Filter) error {
 	switch b.field.Type {
 	case model.FieldTypeString:
@@ -213,6 +377,12 @@ func (b *FieldValueQueryBuilder) querySingleValue(filter *Filter) error {
 		filter.Args = append(filter.Args, value)
 		filter.Where = mergeWheres("AND", filter.Where, newWhere)
 
+	case model.FieldTypeFloat:
+		err := b.queryFloatValues(filter)
+		if err != nil {
+			return err
+		}
+
 	case model.FieldTypeBool:
 		value, err := cast.ToBoolE(b.value)
 		if err != nil {
@@ -223,14 +393,14 @@ func (b *FieldValueQueryBuilder) querySingleValue(filter *Filter) error {
 		filter.Where = mergeWheres("AND", filter.Where, newWhere)
 
 	case model.FieldTypeDatetime:
-		// 时间值查询解析为特定结构体
-		value, ok := b.value.(define.SearchFilterDatetime)
-		if !ok {
-			return fmt.Errorf("invalid datetime value for field %s", b.field.Name)
+		// 时间值按范围查询，支持开放的起止时间和相对时间
+		r, err := timerange.Parse(b.value, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid datetime value for field %s: %w", b.field.Name, err)
 		}
-		newWhere := "item_field_values.value_time >= ? AND item_field_values.value_time <= ?"
-		filter.Args = append(filter.Args, value.Start, value.End)
-		filter.Where = mergeWheres("AND", filter.Where, newWhere)
+		timeFilter := TimeRangeFilter("item_field_values.value_time", r)
+		filter.Args = append(filter.Args, timeFilter.Args...)
+		filter.Where = mergeWheres("AND", filter.Where, timeFilter.Where)
 	}
 
 	return nil
@@ -261,31 +431,182 @@ func (b *FieldValueQueryBuilder) queryArrayValues(filter *Filter) error {
 		filter.Args = append(filter.Args, values)
 		filter.Where = mergeWheres("AND", filter.Where, newWhere)
 
-	case model.FieldTypeBool:
-		// 禁用布尔值数组查询
-		return fmt.Errorf("bool array query is not supported")
+	case model.FieldTypeFloat:
+		// 任一值满足条件即匹配，与单值查询相同
+		err := b.queryFloatValues(filter)
+		if err != nil {
+			return err
+		}
 
-	case model.FieldTypeDatetime:
-		// // 时间值数组查询解析为特定结构体数组
-		// values, ok := b.value.([]define.SearchFilterDatetime)
-		// if !ok {
-		// 	return fmt.Errorf("invalid datetime value for field %s", b.field.Name)
-		// }
-		// wheres := []string{}
-		// for _, value := range values {
-		// 	wheres = append(wheres, "item_field_values.value_time >= ? AND item_field_values.value_time <= ?")
-		// 	filter.Args = append(filter.Args, value.Start, value.End)
-		// }
-		// newWhere := mergeWheres("OR", wheres...)
-		// filter.Where = mergeWheres("AND", filter.Where, newWhere)
-
-		// 禁用时间值数组查询
-		return fmt.Errorf("datetime array query is not supported")
+	case model.FieldTypeBool, model.FieldTypeDatetime:
+		// 任一值满足条件即匹配，与单值查询相同
+		return b.querySingleValue(filter)
+	}
+
+	return nil
+}
+
+// 小数按精确值查询，值为数值或数值数组，范围筛选由 ParseFieldFilter 转换为运算符
+func (b *FieldValueQueryBuilder) queryFloatValues(filter *Filter) error {
+	var values []float64
+	for _, v := range toSlice(b.value) {
+		value, err := cast.ToFloat64E(v)
+		if err != nil {
+			return fmt.Errorf("invalid float value %v for field %s", v, b.field.Name)
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no value given for field %s", b.field.Name)
+	}
+	newWhere := "item_field_values.value_float IN ?"
+	filter.Args = append(filter.Args, values)
+	filter.Where = mergeWheres("AND", filter.Where, newWhere)
+	return nil
+}
+
+// 匹配关联了任一收藏品的值，值为收藏品 ID 或 ID 数组
+func (b *FieldValueQueryBuilder) queryReferenceValues(filter *Filter) error {
+	var itemIDs []uint
+	for _, value := range toSlice(b.value) {
+		id, err := cast.ToUintE(value)
+		if err != nil {
+			return fmt.Errorf("invalid item %v for field %s", value, b.field.Name)
+		}
+		itemIDs = append(itemIDs, id)
+	}
+	if len(itemIDs) == 0 {
+		return fmt.Errorf("no item given for field %s", b.field.Name)
 	}
 
+	newWhere := "item_field_values.value_item IN ?"
+	filter.Args = append(filter.Args, itemIDs)
+	filter.Where = mergeWheres("AND", filter.Where, newWhere)
 	return nil
 }
 
+// 匹配任一选项，值为选项名称或选项 ID，也可以是它们的数组
+func (b *FieldValueQueryBuilder) queryOptionValues(filter *Filter) error {
+	var optionIDs []uint
+	for _, value := range toSlice(b.value) {
+		option, err := resolveFieldOption(b.field, value)
+		if err != nil {
+			return err
+		}
+		optionIDs = append(optionIDs, option.ID)
+	}
+	if len(optionIDs) == 0 {
+		return fmt.Errorf("no option given for field %s", b.field.Name)
+	}
+
+	newWhere := "item_field_values.value_option IN ?"
+	filter.Args = append(filter.Args, optionIDs)
+	filter.Where = mergeWheres("AND", filter.Where, newWhere)
+	return nil
+}
+
+// resolveFieldOption 将选项名称（字符串）或选项 ID（数字）解析为字段的选项，需预加载字段的 Options
+func resolveFieldOption(field model.Field, value interface{}) (model.FieldOption, error) {
+	switch v := value.(type) {
+	case string:
+		for _, option := range field.Options {
+			if option.Value == v {
+				return option, nil
+			}
+		}
+	case float64, int, int64, uint:
+		id := cast.ToUint(v)
+		for _, option := range field.Options {
+			if option.ID == id {
+				return option, nil
+			}
+		}
+	}
+	return model.FieldOption{}, fmt.Errorf("invalid option %v for field %s", value, field.Name)
+}
+
+// FieldValueSortColumn 生成按字段值排序的子查询，数组字段升序时取最小值、降序时取最大值
+// 选择字段按选项顺序排序，关联字段按关联收藏品的名称排序
+func FieldValueSortColumn(field model.Field, desc bool) (string, []interface{}, error) {
+	agg := "MIN"
+	if desc {
+		agg = "MAX"
+	}
+
+	var value, join string
+	switch field.Type {
+	case model.FieldTypeSelect:
+		value, join = "o.sort", " JOIN field_options o ON o.id = v.value_option"
+	case model.FieldTypeReference:
+		value, join = "r.name", " JOIN items r ON r.id = v.value_item AND r.deleted_at IS NULL"
+	default:
+		column, err := fieldValueColumn(field.Type)
+		if err != nil {
+			return "", nil, err
+		}
+		value = "v." + column
+	}
+
+	sql := fmt.Sprintf("(SELECT %s(%s) FROM item_field_values v%s WHERE v.item_id = items.id AND v.field_id = ? AND v.deleted_at IS NULL)", agg, value, join)
+	return sql, []interface{}{field.ID}, nil
+}
+
+// 传给 SQLite 时间函数的时间格式，SQLite 只保留毫秒
+const sqliteTimeFormat = "2006-01-02 15:04:05.000Z07:00"
+
+// TimeRangeFilter 生成时间列的范围条件，起止时间均包含
+func TimeRangeFilter(column string, r timerange.Range) Filter {
+	var filters []Filter
+	if r.Start != nil {
+		filters = append(filters, TimeCompareFilter(column, ">=", *r.Start))
+	}
+	if r.End != nil {
+		filters = append(filters, TimeCompareFilter(column, "<=", *r.End))
+	}
+
+	var filter Filter
+	var wheres []string
+	for _, f := range filters {
+		wheres = append(wheres, f.Where)
+		filter.Args = append(filter.Args, f.Args...)
+	}
+	filter.Where = mergeWheres("AND", wheres...)
+	return filter
+}
+
+// TimeCompareFilter 生成时间列与时间点比较的条件
+// SQLite 中时间以带时区偏移的字符串保存，字段值为 UTC，记录的时间戳为本地时区（夏令时前后偏移不同），
+// 两侧都用 julianday() 转换为 UTC 后比较，精确到毫秒
+func TimeCompareFilter(column string, op string, t time.Time) Filter {
+	return Filter{
+		Where: fmt.Sprintf("julianday(%s) %s julianday(?)", column, op),
+		Args:  []interface{}{t.UTC().Format(sqliteTimeFormat)},
+	}
+}
+
+// toTime 将时间值或 ISO 8601 字符串转换为 UTC 时间，统一时区以便比较
+func toTime(value interface{}) (time.Time, error) {
+	t, err := cast.ToTimeE(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.IsZero() {
+		return time.Time{}, fmt.Errorf("zero time")
+	}
+	return t.UTC(), nil
+}
+
+// toSlice 将数组值转换为 []interface{}，单个值视为只有一个元素的数组
+func toSlice(value interface{}) []interface{} {
+	if value == nil {
+		return nil
+	}
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	return []interface{}{value}
+}
+
 func mergeWheres(op string, wheres ...string) string {
 	if len(wheres) == 0 {
 		return ""
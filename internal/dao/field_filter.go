@@ -0,0 +1,206 @@
+package dao
+
+import (
+	model "collectify/internal/model/db"
+	"collectify/internal/model/define"
+	"collectify/internal/pkg/timerange"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// 比较运算符对应的 SQL 运算符
+var filterCompareOps = map[string]string{
+	define.FilterOpEq:  "=",
+	define.FilterOpLt:  "<",
+	define.FilterOpLte: "<=",
+	define.FilterOpGt:  ">",
+	define.FilterOpGte: ">=",
+}
+
+// LIKE 查询中需要转义的字符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// fieldValueColumn 返回字段类型对应的字段值列
+func fieldValueColumn(fieldType int) (string, error) {
+	switch fieldType {
+	case model.FieldTypeString:
+		return "value_string", nil
+	case model.FieldTypeInt:
+		return "value_int", nil
+	case model.FieldTypeBool:
+		return "value_bool", nil
+	case model.FieldTypeDatetime:
+		return "value_time", nil
+	case model.FieldTypeSelect:
+		return "value_option", nil
+	case model.FieldTypeFloat:
+		return "value_float", nil
+	case model.FieldTypeReference:
+		return "value_item", nil
+	}
+	return "", fmt.Errorf("unsupported field type: %d", fieldType)
+}
+
+// isOrderedFieldType 字段类型的值是否可以比较大小
+func isOrderedFieldType(fieldType int) bool {
+	switch fieldType {
+	case model.FieldTypeString, model.FieldTypeInt, model.FieldTypeDatetime, model.FieldTypeFloat:
+		return true
+	}
+	return false
+}
+
+// buildOperator 按运算符生成筛选条件
+// ne 和 is_empty 需要检查收藏品的所有字段值，使用 NOT EXISTS 子查询，其余条件作用于关联的字段值行
+func (b *FieldValueQueryBuilder) buildOperator(ff define.FieldFilter) (Filter, error) {
+	column, err := fieldValueColumn(b.field.Type)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	switch ff.Op {
+	case define.FilterOpNe:
+		value, err := b.operand(ff.Value)
+		if err != nil {
+			return Filter{}, err
+		}
+		return b.notExists("v."+column+" = ?", value), nil
+
+	case define.FilterOpIsEmpty:
+		// 未指定值时表示为空
+		empty := true
+		if ff.Value != nil {
+			empty, err = cast.ToBoolE(ff.Value)
+			if err != nil {
+				return Filter{}, fmt.Errorf("invalid is_empty value for field %s", b.field.Name)
+			}
+		}
+		if empty {
+			return b.notExists(""), nil
+		}
+		return b.matchRow(""), nil
+	}
+
+	column = "item_field_values." + column
+	switch ff.Op {
+	case define.FilterOpEq, define.FilterOpLt, define.FilterOpLte, define.FilterOpGt, define.FilterOpGte:
+		if ff.Op != define.FilterOpEq && !isOrderedFieldType(b.field.Type) {
+			return Filter{}, fmt.Errorf("operator %s is not supported for field %s", ff.Op, b.field.Name)
+		}
+		value, err := b.operand(ff.Value)
+		if err != nil {
+			return Filter{}, err
+		}
+		return b.matchRow(column+" "+filterCompareOps[ff.Op]+" ?", value), nil
+
+	case define.FilterOpBetween:
+		if !isOrderedFieldType(b.field.Type) {
+			return Filter{}, fmt.Errorf("operator %s is not supported for field %s", ff.Op, b.field.Name)
+		}
+		bounds := toSlice(ff.Value)
+		if len(bounds) != 2 {
+			return Filter{}, fmt.Errorf("between requires two values for field %s", b.field.Name)
+		}
+		low, err := b.operand(bounds[0])
+		if err != nil {
+			return Filter{}, err
+		}
+		high, err := b.operand(bounds[1])
+		if err != nil {
+			return Filter{}, err
+		}
+		return b.matchRow(column+" BETWEEN ? AND ?", low, high), nil
+
+	case define.FilterOpIn:
+		var values []interface{}
+		for _, v := range toSlice(ff.Value) {
+			value, err := b.operand(v)
+			if err != nil {
+				return Filter{}, err
+			}
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return Filter{}, fmt.Errorf("no value given for field %s", b.field.Name)
+		}
+		return b.matchRow(column+" IN ?", values), nil
+
+	case define.FilterOpContains, define.FilterOpStartsWith:
+		if b.field.Type != model.FieldTypeString {
+			return Filter{}, fmt.Errorf("operator %s is not supported for field %s", ff.Op, b.field.Name)
+		}
+		value, err := cast.ToStringE(ff.Value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid value %v for field %s", ff.Value, b.field.Name)
+		}
+		pattern := likeEscaper.Replace(value) + "%"
+		if ff.Op == define.FilterOpContains {
+			pattern = "%" + pattern
+		}
+		return b.matchRow(column+` LIKE ? ESCAPE '\'`, pattern), nil
+	}
+
+	return Filter{}, fmt.Errorf("unsupported operator %s for field %s", ff.Op, b.field.Name)
+}
+
+// matchRow 关联的字段值行属于该字段且满足条件
+func (b *FieldValueQueryBuilder) matchRow(where string, args ...interface{}) Filter {
+	wheres := []string{"item_field_values.field_id = ?"}
+	if where != "" {
+		wheres = append(wheres, where)
+	}
+	return Filter{
+		Where: mergeWheres("AND", wheres...),
+		Args:  append([]interface{}{b.field.ID}, args...),
+	}
+}
+
+// notExists 收藏品没有该字段满足条件的值，where 为空时表示没有该字段的值
+func (b *FieldValueQueryBuilder) notExists(where string, args ...interface{}) Filter {
+	subquery := "SELECT 1 FROM item_field_values v WHERE v.item_id = items.id AND v.field_id = ? AND v.deleted_at IS NULL"
+	if where != "" {
+		subquery += " AND " + where
+	}
+	b.itemLevel = true
+	return Filter{
+		Where: fmt.Sprintf("NOT EXISTS (%s)", subquery),
+		Args:  append([]interface{}{b.field.ID}, args...),
+	}
+}
+
+// operand 将筛选值转换为字段值列的类型
+func (b *FieldValueQueryBuilder) operand(value interface{}) (interface{}, error) {
+	var result interface{}
+	var err error
+
+	switch b.field.Type {
+	case model.FieldTypeString:
+		result, err = cast.ToStringE(value)
+	case model.FieldTypeInt:
+		result, err = cast.ToIntE(value)
+	case model.FieldTypeBool:
+		result, err = cast.ToBoolE(value)
+	case model.FieldTypeDatetime:
+		// 支持 ISO 8601 和 30 days ago 等相对时间
+		var t time.Time
+		t, err = timerange.ParsePoint(value, time.Now())
+		result = t.UTC()
+	case model.FieldTypeFloat:
+		result, err = cast.ToFloat64E(value)
+	case model.FieldTypeSelect:
+		var option model.FieldOption
+		option, err = resolveFieldOption(b.field, value)
+		result = option.ID
+	case model.FieldTypeReference:
+		result, err = cast.ToUintE(value)
+	default:
+		err = fmt.Errorf("unsupported field type: %d", b.field.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %v for field %s", value, b.field.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"collectify/internal/pkg/normalize"
+
+	"gorm.io/gorm"
+)
+
+// 保存了名称搜索键的表
+var nameKeyTables = []string{"items", "item_aliases", "tags", "categories", "collections"}
+
+// InitNameKeys 为没有搜索键的记录（包括已删除的）生成名称的搜索键，如升级前创建的记录
+func InitNameKeys(tx *gorm.DB) error {
+	for _, table := range nameKeyTables {
+		var rows []struct {
+			ID   uint
+			Name string
+		}
+		err := tx.Raw("SELECT id, name FROM " + table + " WHERE name_key = '' AND name <> ''").Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		err = tx.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				err := tx.Exec("UPDATE "+table+" SET name_key = ? WHERE id = ?", normalize.Key(row.Name), row.ID).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NameKeyFilter 按名称搜索，搜索键包含折叠后的 text 即匹配，column 为搜索键列，如 tags.name_key
+func NameKeyFilter(column, text string) Filter {
+	return Filter{
+		Where: column + ` LIKE ? ESCAPE '\'`,
+		Args:  []interface{}{nameKeyPattern(text)},
+	}
+}
+
+// ItemNameKeyFilter 按名称搜索收藏品，名称或任一别名的搜索键包含折叠后的 text 即匹配
+func ItemNameKeyFilter(text string) Filter {
+	return ItemNameFilter(`%s.name_key LIKE ? ESCAPE '\'`, nameKeyPattern(text))
+}
+
+func nameKeyPattern(text string) string {
+	return "%" + likeEscaper.Replace(normalize.Fold(text)) + "%"
+}
@@ -0,0 +1,231 @@
+package dao
+
+import (
+	model "collectify/internal/model/db"
+	"collectify/internal/model/define"
+	"collectify/internal/pkg/query"
+	"collectify/internal/pkg/timerange"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"gorm.io/gorm"
+)
+
+// 查询语句中的运算符对应的筛选运算符，: 的含义取决于条件的类型
+var queryOps = map[string]string{
+	query.OpEq:  define.FilterOpEq,
+	query.OpNe:  define.FilterOpNe,
+	query.OpLt:  define.FilterOpLt,
+	query.OpLte: define.FilterOpLte,
+	query.OpGt:  define.FilterOpGt,
+	query.OpGte: define.FilterOpGte,
+}
+
+// 查询语句中的状态名称
+var queryStatusNames = map[string]int{
+	"todo":        model.ItemStatusTodo,
+	"in_progress": model.ItemStatusInProgress,
+	"paused":      model.ItemStatusPaused,
+	"abandoned":   model.ItemStatusAbandoned,
+	"completed":   model.ItemStatusCompleted,
+}
+
+// 查询语句中的时间属性
+var queryTimeColumns = map[string]string{
+	"created":      "items.created_at",
+	"created_at":   "items.created_at",
+	"updated":      "items.updated_at",
+	"updated_at":   "items.updated_at",
+	"completed":    "items.completed_at",
+	"completed_at": "items.completed_at",
+}
+
+// QueryCompiler 将查询语句编译为筛选条件
+// 条件的键先匹配收藏品属性（name、tag、collection、status、rating、priority 和时间），再按名称匹配自定义字段（不区分大小写）
+// 每个条件都编译为只依赖 items 表的条件，可以任意组合
+type QueryCompiler struct {
+	tx     *gorm.DB
+	fields map[string]model.Field
+}
+
+// NewQueryCompiler fields 为可以在查询中使用的自定义字段，需预加载 Options
+func NewQueryCompiler(tx *gorm.DB, fields []model.Field) *QueryCompiler {
+	fieldMap := make(map[string]model.Field, len(fields))
+	for _, field := range fields {
+		fieldMap[strings.ToLower(field.Name)] = field
+	}
+	return &QueryCompiler{
+		tx:     tx,
+		fields: fieldMap,
+	}
+}
+
+func (c *QueryCompiler) Compile(node query.Node) (Filter, error) {
+	switch n := node.(type) {
+	case query.And:
+		return c.compileAll("AND", n.Nodes)
+	case query.Or:
+		return c.compileAll("OR", n.Nodes)
+	case query.Not:
+		filter, err := c.Compile(n.Node)
+		if err != nil {
+			return Filter{}, err
+		}
+		return Filter{Where: "NOT (" + filter.Where + ")", Args: filter.Args}, nil
+	case query.Term:
+		return c.compileTerm(n)
+	}
+	return Filter{}, &query.Error{Pos: 1, Msg: "unsupported query node"}
+}
+
+func (c *QueryCompiler) compileAll(op string, nodes []query.Node) (Filter, error) {
+	var wheres []string
+	var args []interface{}
+	for _, node := range nodes {
+		filter, err := c.Compile(node)
+		if err != nil {
+			return Filter{}, err
+		}
+		wheres = append(wheres, filter.Where)
+		args = append(args, filter.Args...)
+	}
+	return Filter{Where: mergeWheres(op, wheres...), Args: args}, nil
+}
+
+func (c *QueryCompiler) compileTerm(term query.Term) (Filter, error) {
+	key := strings.ToLower(term.Key)
+	if column, ok := queryTimeColumns[key]; ok {
+		return c.compileTime(term, column)
+	}
+
+	switch key {
+	case "", "name":
+		return c.compileName(term)
+	case "tag", "tags":
+		return c.compileRelation(term, "SELECT 1 FROM item_tags it JOIN tags t ON t.id = it.tag_id AND t.deleted_at IS NULL WHERE it.item_id = items.id AND t.name = ?")
+	case "collection", "collections":
+		return c.compileRelation(term, "SELECT 1 FROM collection_items ci JOIN collections co ON co.id = ci.collection_id AND co.deleted_at IS NULL WHERE ci.item_id = items.id AND co.name = ?")
+	case "status":
+		status, ok := queryStatusNames[strings.ToLower(term.Value)]
+		if !ok {
+			var err error
+			if status, err = cast.ToIntE(term.Value); err != nil {
+				return Filter{}, term.Errorf("invalid status %q", term.Value)
+			}
+		}
+		return c.compileCompare(term, "items.status", status, false)
+	case "rating":
+		rating, err := cast.ToFloat64E(term.Value)
+		if err != nil {
+			return Filter{}, term.Errorf("invalid rating %q", term.Value)
+		}
+		return c.compileCompare(term, "items.rating", rating, true)
+	case "priority":
+		priority, err := cast.ToIntE(term.Value)
+		if err != nil {
+			return Filter{}, term.Errorf("invalid priority %q", term.Value)
+		}
+		return c.compileCompare(term, "items.priority", priority, true)
+	}
+
+	field, ok := c.fields[key]
+	if !ok {
+		if len(c.fields) == 0 {
+			return Filter{}, term.Errorf("unknown field %q, custom fields require category_id", term.Key)
+		}
+		return Filter{}, term.Errorf("unknown field %q", term.Key)
+	}
+	return c.compileField(term, field)
+}
+
+// compileName 按名称或别名搜索，: 和只有值的条件匹配名称的一部分，不区分变音符号并支持拼音
+func (c *QueryCompiler) compileName(term query.Term) (Filter, error) {
+	switch term.Op {
+	case "", query.OpMatch:
+		return ItemNameKeyFilter(term.Value), nil
+	case query.OpEq:
+		return ItemNameFilter("%s.name = ?", term.Value), nil
+	case query.OpNe:
+		filter := ItemNameFilter("%s.name = ?", term.Value)
+		filter.Where = "NOT " + filter.Where
+		return filter, nil
+	}
+	return Filter{}, term.Errorf("operator %q is not supported for name", term.Op)
+}
+
+// compileRelation 按名称匹配标签或收藏夹，subquery 以名称为参数
+func (c *QueryCompiler) compileRelation(term query.Term, subquery string) (Filter, error) {
+	switch term.Op {
+	case query.OpMatch, query.OpEq:
+		return Filter{Where: "EXISTS (" + subquery + ")", Args: []interface{}{term.Value}}, nil
+	case query.OpNe:
+		return Filter{Where: "NOT EXISTS (" + subquery + ")", Args: []interface{}{term.Value}}, nil
+	}
+	return Filter{}, term.Errorf("operator %q is not supported for %s", term.Op, term.Key)
+}
+
+// compileCompare 比较收藏品属性，ordered 为 false 时只能判断是否相等；列可以为空，不等于时包含空值
+func (c *QueryCompiler) compileCompare(term query.Term, column string, value interface{}, ordered bool) (Filter, error) {
+	op := define.FilterOpEq
+	if term.Op != query.OpMatch {
+		op = queryOps[term.Op]
+	}
+
+	switch op {
+	case define.FilterOpEq:
+	case define.FilterOpNe:
+		return Filter{Where: "(" + column + " IS NULL OR " + column + " <> ?)", Args: []interface{}{value}}, nil
+	default:
+		if !ordered {
+			return Filter{}, term.Errorf("operator %q is not supported for %s", term.Op, term.Key)
+		}
+	}
+	return Filter{Where: column + " " + filterCompareOps[op] + " ?", Args: []interface{}{value}}, nil
+}
+
+// compileTime 按时间范围筛选，: 和 = 匹配范围内的时间，比较时大于取范围的结束时间、小于取开始时间
+// 如 created>2024-01-01 表示 2024 年 1 月 1 日之后创建
+func (c *QueryCompiler) compileTime(term query.Term, column string) (Filter, error) {
+	r, err := timerange.Parse(term.Value, time.Now())
+	if err != nil {
+		return Filter{}, term.Errorf("invalid time %q", term.Value)
+	}
+
+	var bound *time.Time
+	switch term.Op {
+	case query.OpMatch, query.OpEq:
+		return TimeRangeFilter(column, r), nil
+	case query.OpLt, query.OpGte:
+		bound = r.Start
+	case query.OpLte, query.OpGt:
+		bound = r.End
+	default:
+		return Filter{}, term.Errorf("operator %q is not supported for %s", term.Op, term.Key)
+	}
+	if bound == nil {
+		return Filter{}, term.Errorf("invalid time %q", term.Value)
+	}
+	return TimeCompareFilter(column, filterCompareOps[queryOps[term.Op]], *bound), nil
+}
+
+// compileField 按自定义字段筛选，: 对字符串字段表示包含，对时间字段表示时间范围，其余类型表示等于
+func (c *QueryCompiler) compileField(term query.Term, field model.Field) (Filter, error) {
+	var value interface{}
+	switch {
+	case term.Op != query.OpMatch:
+		value = define.FieldFilter{Op: queryOps[term.Op], Value: term.Value}
+	case field.Type == model.FieldTypeString:
+		value = define.FieldFilter{Op: define.FilterOpContains, Value: term.Value}
+	case field.Type == model.FieldTypeDatetime:
+		value = term.Value
+	default:
+		value = define.FieldFilter{Op: define.FilterOpEq, Value: term.Value}
+	}
+
+	filter, err := NewFieldValueQueryBuilder(c.tx, field, value).BuildExists()
+	if err != nil {
+		return Filter{}, term.Errorf("%v", err)
+	}
+	return filter, nil
+}
@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 收藏品的全文索引，rowid 为收藏品 ID，索引名称（包括别名）、简介、感想、标签名和字符串字段值
+// 使用 FTS5 的 trigram 分词器，不区分大小写，可以匹配任意语言（包括中文）中的子串，查询的词至少需要 3 个字符
+const itemIndexTable = "items_fts"
+
+// 索引列在相关度计算中的权重，依次为 name、description、notes、tags、fields
+const itemIndexRank = "bm25(items_fts, 10.0, 2.0, 1.0, 5.0, 3.0)"
+
+// 从收藏品生成索引内容，只包含未删除的收藏品、标签、字段和字段值
+const itemIndexSelect = `SELECT items.id,
+	items.name || COALESCE(' ' || (SELECT group_concat(item_aliases.name, ' ') FROM item_aliases WHERE item_aliases.item_id = items.id), ''),
+	items.description, items.notes,
+	COALESCE((SELECT group_concat(tags.name, ' ') FROM item_tags JOIN tags ON tags.id = item_tags.tag_id
+		WHERE item_tags.item_id = items.id AND tags.deleted_at IS NULL), ''),
+	COALESCE((SELECT group_concat(v.value_string, ' ') FROM item_field_values v JOIN fields f ON f.id = v.field_id
+		WHERE v.item_id = items.id AND v.deleted_at IS NULL AND f.deleted_at IS NULL AND v.value_string <> ''), '')
+FROM items WHERE items.deleted_at IS NULL`
+
+const itemIndexInsert = "INSERT INTO items_fts (rowid, name, description, notes, tags, fields) "
+
+// InitItemIndex 创建全文索引表，新建时为已有的收藏品建立索引
+func InitItemIndex(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(itemIndexTable) {
+		return nil
+	}
+
+	return tx.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("CREATE VIRTUAL TABLE items_fts USING fts5(name, description, notes, tags, fields, tokenize = 'trigram')").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(itemIndexInsert + itemIndexSelect).Error
+	})
+}
+
+// IndexItems 重建收藏品的索引，已删除的收藏品从索引中移除
+func IndexItems(tx *gorm.DB, itemIDs []uint) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM items_fts WHERE rowid IN ?", itemIDs).Error; err != nil {
+		return err
+	}
+	return tx.Exec(itemIndexInsert+itemIndexSelect+" AND items.id IN ?", itemIDs).Error
+}
+
+// IndexItemsByFilter 重建满足条件的收藏品（包括已删除的）的索引，如使用了某个标签的收藏品
+func IndexItemsByFilter(tx *gorm.DB, filter Filter) error {
+	subquery := "SELECT items.id FROM items WHERE " + filter.Where
+	if err := tx.Exec("DELETE FROM items_fts WHERE rowid IN ("+subquery+")", filter.Args...).Error; err != nil {
+		return err
+	}
+	return tx.Exec(itemIndexInsert+itemIndexSelect+" AND "+filter.Where, filter.Args...).Error
+}
+
+// PruneItemIndex 移除已彻底删除的收藏品的索引
+func PruneItemIndex(tx *gorm.DB) error {
+	return tx.Exec("DELETE FROM items_fts WHERE rowid NOT IN (SELECT id FROM items)").Error
+}
+
+// FullTextQuery 全文搜索条件，搜索内容按空白拆分为词，收藏品需匹配所有词
+type FullTextQuery struct {
+	match string   // FTS5 查询，由至少 3 个字符的词组成
+	likes []string // 不足 3 个字符的词，trigram 分词器无法匹配，改用 LIKE 查询索引内容
+}
+
+// NewFullTextQuery 解析搜索内容，内容为空时返回 false
+func NewFullTextQuery(text string) (FullTextQuery, bool) {
+	var q FullTextQuery
+	var phrases []string
+	for _, word := range strings.Fields(text) {
+		if len([]rune(word)) >= 3 {
+			// 作为短语查询，避免词中的符号被解析为 FTS5 语法
+			phrases = append(phrases, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`)
+		} else {
+			q.likes = append(q.likes, word)
+		}
+	}
+	q.match = strings.Join(phrases, " AND ")
+	return q, q.match != "" || len(q.likes) > 0
+}
+
+// Filter 生成匹配的收藏品的筛选条件
+func (q FullTextQuery) Filter() Filter {
+	var wheres []string
+	var args []interface{}
+	if q.match != "" {
+		wheres = append(wheres, "items_fts MATCH ?")
+		args = append(args, q.match)
+	}
+	for _, word := range q.likes {
+		pattern := "%" + likeEscaper.Replace(word) + "%"
+		var columns []string
+		for _, column := range []string{"name", "description", "notes", "tags", "fields"} {
+			columns = append(columns, "items_fts."+column+` LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+		wheres = append(wheres, mergeWheres("OR", columns...))
+	}
+	return Filter{
+		Where: "items.id IN (SELECT rowid FROM items_fts WHERE " + strings.Join(wheres, " AND ") + ")",
+		Args:  args,
+	}
+}
+
+// Rank 按相关度排序，只有不足 3 个字符的词时无法计算相关度，返回 false
+func (q FullTextQuery) Rank() (OrderBy, bool) {
+	if q.match == "" {
+		return OrderBy{}, false
+	}
+	return OrderBy{
+		Column: "(SELECT " + itemIndexRank + " FROM items_fts WHERE items_fts MATCH ? AND items_fts.rowid = items.id)",
+		Args:   []interface{}{q.match},
+	}, true
+}
+
+// Snippets 返回收藏品中匹配内容的摘要，匹配的部分用 <mark></mark> 标记
+func (q FullTextQuery) Snippets(tx *gorm.DB, itemIDs []uint) (map[uint]string, error) {
+	snippets := make(map[uint]string)
+	if q.match == "" || len(itemIDs) == 0 {
+		return snippets, nil
+	}
+
+	var rows []struct {
+		ID      uint
+		Snippet string
+	}
+	err := tx.Raw("SELECT rowid AS id, snippet(items_fts, -1, '<mark>', '</mark>', '…', 16) AS snippet FROM items_fts WHERE items_fts MATCH ? AND rowid IN ?", q.match, itemIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		snippets[row.ID] = row.Snippet
+	}
+	return snippets, nil
+}
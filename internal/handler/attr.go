@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"collectify/internal/config"
 	"collectify/internal/model/common"
+	define "collectify/internal/model/define"
 	"collectify/internal/pkg/e"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +20,21 @@ func GetID(c *gin.Context, idKey string) (uint, error) {
 	return id, nil
 }
 
+// 获取当前登录用户的ID，未启用认证或匿名访问时为 0
+func GetUserID(c *gin.Context) uint {
+	return c.GetUint("user_id")
+}
+
+// 是否为匿名访问（启用认证但未登录），匿名访问时不展示私密藏品
+func IsAnonymous(c *gin.Context) bool {
+	return config.GetConfig().Auth.Enable && GetUserID(c) == 0
+}
+
+// 获取当前用户访问的藏品库所有者ID，用于数据隔离，未启用认证或匿名访问时为公开的藏品库，见 service.PublicOwnerID
+func GetOwnerID(c *gin.Context) uint {
+	return c.GetUint("owner_id")
+}
+
 // 从查询参数中获取分页参数
 func GetPagination(c *gin.Context) (common.Pagination, error) {
 	page := cast.ToInt(c.Query("page"))
@@ -34,3 +51,34 @@ func GetPagination(c *gin.Context) (common.Pagination, error) {
 		Disable: noPaging,
 	}, nil
 }
+
+// 处理重复检查结果，返回是否继续创建
+// 仅存在已删除的重复记录时，根据 onDeleted 恢复已删除的记录或继续创建新记录，否则返回重复错误
+func ResolveDuplicate(c *gin.Context, id uint, isDeleted bool, onDeleted string, restore func(id uint, userID uint) error) bool {
+	if id == 0 {
+		return true
+	}
+
+	if isDeleted {
+		switch onDeleted {
+		case define.OnDeletedCreate:
+			return true
+		case define.OnDeletedRestore:
+			if err := restore(id, GetOwnerID(c)); err != nil {
+				Fail(c, err)
+				return false
+			}
+			SuccessWithData(c, map[string]interface{}{
+				"id":       id,
+				"restored": true,
+			})
+			return false
+		}
+	}
+
+	FailWithData(c, e.ErrDuplicated, map[string]interface{}{
+		"id":        id,
+		"isDeleted": isDeleted,
+	})
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	model "collectify/internal/model/db"
 	define "collectify/internal/model/define"
 	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/normalize"
 	"collectify/internal/service"
 	"errors"
 
@@ -21,25 +22,25 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
+	userID := GetOwnerID(c)
+
 	// 检查是否重复
-	uniqueFields := map[string]interface{}{"name": req.Name}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"name": req.Name}, userID)
 	filters := []dao.Filter{}
 	id, isDeleted, err := dao.DuplicateCheck[model.Category](conn.GetDB(), uniqueFields, filters)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
-	if id != 0 {
-		FailWithData(c, e.ErrDuplicated, map[string]interface{}{
-			"id":        id,
-			"isDeleted": isDeleted,
-		})
+	if !ResolveDuplicate(c, id, isDeleted, req.OnDeleted, service.RestoreCategory) {
 		return
 	}
 
 	// 创建
 	category := &model.Category{
-		Name: req.Name,
+		UserID:  userID,
+		Name:    req.Name,
+		NameKey: normalize.Key(req.Name),
 	}
 	err = dao.Create(conn.GetDB(), category)
 	if err != nil {
@@ -57,7 +58,7 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	err = service.DeleteCategory(id)
+	err = service.DeleteCategory(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -73,7 +74,7 @@ func RestoreCategory(c *gin.Context) {
 		return
 	}
 
-	err = service.RestoreCategory(id)
+	err = service.RestoreCategory(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -95,13 +96,26 @@ func RenameCategory(c *gin.Context) {
 		return
 	}
 
+	userID := GetOwnerID(c)
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": categoryID}, userID)
+	category, err := dao.Get[model.Category](conn.GetDB(), uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Fail(c, e.ErrNotFound)
+			return
+		}
+		Fail(c, err)
+		return
+	}
+
 	// 检查是否重复
-	uniqueFields := map[string]interface{}{
-		"name": req.Name,
+	uniqueFields = map[string]interface{}{
+		"user_id": category.UserID,
+		"name":    req.Name,
 	}
 	filters := []dao.Filter{
 		{
-			Where: "id != ?",
+			Where: "id != ? AND deleted_at IS NULL",
 			Args:  []interface{}{categoryID},
 		},
 	}
@@ -119,7 +133,7 @@ func RenameCategory(c *gin.Context) {
 	}
 
 	uniqueFields = map[string]interface{}{"id": categoryID}
-	updateFields := map[string]interface{}{"name": req.Name}
+	updateFields := map[string]interface{}{"name": req.Name, "name_key": normalize.Key(req.Name)}
 	err = dao.Update[model.Category](conn.GetDB(), uniqueFields, updateFields)
 	if err != nil {
 		Fail(c, err)
@@ -136,8 +150,8 @@ func GetCategory(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
-	preloads := []string{"Fields"}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
+	preloads := []string{"Fields", "Fields.Options"}
 	category, err := dao.Get[model.Category](conn.GetDB(), uniqueFields, preloads...)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -157,14 +171,11 @@ func GetCategory(c *gin.Context) {
 func ListCategory(c *gin.Context) {
 	name := c.Query("name")
 
-	var filters []dao.Filter
+	filters := dao.OwnerFilter("", GetOwnerID(c))
 	var orderBy []dao.OrderBy
 
 	if name != "" {
-		filters = append(filters, dao.Filter{
-			Where: "name LIKE ?",
-			Args:  []interface{}{"%" + name + "%"},
-		})
+		filters = append(filters, dao.NameKeyFilter("name_key", name))
 	}
 
 	// 不分页
@@ -8,6 +8,8 @@ import (
 	model "collectify/internal/model/db"
 	define "collectify/internal/model/define"
 	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/normalize"
+	"collectify/internal/service"
 	"errors"
 
 	"github.com/gin-gonic/gin"
@@ -21,25 +23,25 @@ func CreateCollection(c *gin.Context) {
 		return
 	}
 
+	userID := GetOwnerID(c)
+
 	// 检查是否重复
-	uniqueFields := map[string]interface{}{"name": req.Name}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"name": req.Name}, userID)
 	filters := []dao.Filter{}
 	id, isDeleted, err := dao.DuplicateCheck[model.Collection](conn.GetDB(), uniqueFields, filters)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
-	if id != 0 {
-		FailWithData(c, e.ErrDuplicated, map[string]interface{}{
-			"id":        id,
-			"isDeleted": isDeleted,
-		})
+	if !ResolveDuplicate(c, id, isDeleted, req.OnDeleted, service.RestoreCollection) {
 		return
 	}
 
 	// 创建
 	collection := &model.Collection{
-		Name: req.Name,
+		UserID:  userID,
+		Name:    req.Name,
+		NameKey: normalize.Key(req.Name),
 	}
 	err = dao.Create(conn.GetDB(), collection)
 	if err != nil {
@@ -58,7 +60,7 @@ func DeleteCollection(c *gin.Context) {
 	}
 
 	isSoftDelete := config.GetConfig().RecycleBin.Enable
-	uniqueFields := map[string]interface{}{"id": id}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
 	err = dao.Delete[model.Collection](conn.GetDB(), uniqueFields, isSoftDelete)
 	if err != nil {
 		Fail(c, err)
@@ -75,8 +77,7 @@ func RestoreCollection(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
-	err = dao.Restore[model.Collection](conn.GetDB(), uniqueFields)
+	err = service.RestoreCollection(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -97,9 +98,13 @@ func UpdateCollection(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
 	collection, err := dao.Get[model.Collection](conn.GetDB(), uniqueFields)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Fail(c, e.ErrNotFound)
+			return
+		}
 		Fail(c, err)
 		return
 	}
@@ -107,15 +112,16 @@ func UpdateCollection(c *gin.Context) {
 	// 如果名称不同，则检查是否重复
 	if req.Name != "" && collection.Name != req.Name {
 		uniqueFields := map[string]interface{}{
-			"name": req.Name,
+			"user_id": collection.UserID,
+			"name":    req.Name,
 		}
 		filters := []dao.Filter{
 			{
-				Where: "id != ?",
+				Where: "id != ? AND deleted_at IS NULL",
 				Args:  []interface{}{id},
 			},
 		}
-		id, isDeleted, err := dao.DuplicateCheck[model.Tag](conn.GetDB(), uniqueFields, filters)
+		id, isDeleted, err := dao.DuplicateCheck[model.Collection](conn.GetDB(), uniqueFields, filters)
 		if err != nil {
 			Fail(c, err)
 			return
@@ -132,6 +138,7 @@ func UpdateCollection(c *gin.Context) {
 	updateFields := map[string]interface{}{}
 	if req.Name != "" {
 		updateFields["name"] = req.Name
+		updateFields["name_key"] = normalize.Key(req.Name)
 	}
 	if req.Description != "" {
 		updateFields["description"] = req.Description
@@ -158,7 +165,7 @@ func GetCollection(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
 	collection, err := dao.Get[model.Collection](conn.GetDB(), uniqueFields)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -178,14 +185,11 @@ func GetCollection(c *gin.Context) {
 func ListCollection(c *gin.Context) {
 	name := c.Query("name")
 
-	var filters []dao.Filter
+	filters := dao.OwnerFilter("", GetOwnerID(c))
 	var orderBy []dao.OrderBy
 
 	if name != "" {
-		filters = append(filters, dao.Filter{
-			Where: "name LIKE ?",
-			Args:  []interface{}{"%" + name + "%"},
-		})
+		filters = append(filters, dao.NameKeyFilter("name_key", name))
 	}
 
 	// 不分页
@@ -1 +1,62 @@
 package handler
+
+import (
+	define "collectify/internal/model/define"
+	"collectify/internal/pkg/e"
+	"collectify/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListDeleted(c *gin.Context) {
+	modelType := c.Query("type")
+	switch modelType {
+	case "", "category", "collection", "field", "item", "tag":
+	default:
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	records, err := service.ListDeleted(modelType, GetOwnerID(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	SuccessWithData(c, define.SearchResp{
+		List:  records,
+		Total: int64(len(records)),
+	})
+}
+
+func RestoreDeleted(c *gin.Context) {
+	var req define.DeletedReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, err)
+		return
+	}
+
+	err := service.RestoreDeleted(req.List, GetOwnerID(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
+func PurgeDeleted(c *gin.Context) {
+	var req define.DeletedReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, err)
+		return
+	}
+
+	err := service.PurgeDeleted(req.List, GetOwnerID(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func CreateField(c *gin.Context) {
@@ -19,7 +20,20 @@ func CreateField(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{
+	// 检查分类是否属于当前用户
+	userID := GetOwnerID(c)
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": req.CategoryID}, userID)
+	category, err := dao.Get[model.Category](conn.GetDB(), uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Fail(c, e.ErrNotFound)
+			return
+		}
+		Fail(c, err)
+		return
+	}
+
+	uniqueFields = map[string]interface{}{
 		"category_id": req.CategoryID,
 		"name":        req.Name,
 	}
@@ -29,30 +43,39 @@ func CreateField(c *gin.Context) {
 		Fail(c, err)
 		return
 	}
-	if id != 0 {
-		FailWithData(c, e.ErrDuplicated, map[string]interface{}{
-			"id":        id,
-			"isDeleted": isDeleted,
-		})
+	if !ResolveDuplicate(c, id, isDeleted, req.OnDeleted, service.RestoreField) {
+		return
+	}
+
+	if err := service.CheckFieldOptions(req.Type, req.Options); err != nil {
+		Fail(c, err)
 		return
 	}
 
-	if req.Type == model.FieldTypeBool && req.IsArray {
-		Fail(c, e.ErrInvalidParams.Wrap(errors.New("bool array query is not supported")))
+	if err := service.CheckFieldUnit(req.Type, req.Unit, req.Precision); err != nil {
+		Fail(c, err)
 		return
 	}
 
-	if req.Type == model.FieldTypeDatetime && req.IsArray {
-		Fail(c, e.ErrInvalidParams.Wrap(errors.New("datetime array query is not supported")))
+	if err := service.CheckFieldTarget(req.Type, req.TargetCategoryID, category.UserID); err != nil {
+		Fail(c, err)
 		return
 	}
 
 	field := &model.Field{
+		UserID:     category.UserID,
 		CategoryID: req.CategoryID,
 		Name:       req.Name,
 		Type:       req.Type,
 		IsArray:    req.IsArray,
 		Required:   req.Required,
+		Unit:       req.Unit,
+		Precision:  req.Precision,
+
+		TargetCategoryID: req.TargetCategoryID,
+	}
+	for idx, option := range req.Options {
+		field.Options = append(field.Options, option.ToDB(idx))
 	}
 
 	err = dao.Create(conn.GetDB(), field)
@@ -64,6 +87,28 @@ func CreateField(c *gin.Context) {
 	Success(c)
 }
 
+func UpdateFieldOptions(c *gin.Context) {
+	id, err := GetID(c, "id")
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	var req define.UpdateFieldOptionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, err)
+		return
+	}
+
+	err = service.UpdateFieldOptions(id, GetOwnerID(c), req.Options)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
 func DeleteField(c *gin.Context) {
 	id, err := GetID(c, "id")
 	if err != nil {
@@ -71,7 +116,7 @@ func DeleteField(c *gin.Context) {
 		return
 	}
 
-	err = service.DeleteField(id)
+	err = service.DeleteField(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -87,7 +132,7 @@ func RestoreField(c *gin.Context) {
 		return
 	}
 
-	err = service.RestoreField(id)
+	err = service.RestoreField(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -19,6 +19,7 @@ func CreateItem(c *gin.Context) {
 	}
 
 	item := req.Item.ToDB()
+	item.UserID = GetOwnerID(c)
 	item.CategoryID = req.CategoryID
 
 	err := service.CreateItem(item, req.Item.Values)
@@ -39,6 +40,7 @@ func UpdateItem(c *gin.Context) {
 
 	item := req.Item.ToDB()
 	item.ID = req.ID
+	item.UserID = GetOwnerID(c)
 
 	err := service.UpdateItem(item, req.Item.Values)
 	if err != nil {
@@ -56,7 +58,7 @@ func DeleteItem(c *gin.Context) {
 		return
 	}
 
-	err = service.DeleteItem(id)
+	err = service.DeleteItem(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -72,7 +74,7 @@ func RestoreItem(c *gin.Context) {
 		return
 	}
 
-	err = service.RestoreItem(id)
+	err = service.RestoreItem(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -88,7 +90,7 @@ func ListItems(c *gin.Context) {
 		return
 	}
 
-	items, total, err := service.ListItems(pagination)
+	items, total, err := service.ListItems(pagination, c.Query("sort"), GetOwnerID(c), IsAnonymous(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -123,7 +125,7 @@ func SearchItems(c *gin.Context) {
 		req.Filters = nil
 	}
 
-	items, total, err := service.SearchItems(req.CategoryID, req.Name, req.TagIDs, req.CollectionIDs, req.Filters, pagination)
+	items, total, err := service.SearchItems(req, pagination, GetOwnerID(c), IsAnonymous(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -131,6 +133,9 @@ func SearchItems(c *gin.Context) {
 
 	itemDetails := make([]define.ItemDetail, len(items))
 	for i, item := range items {
+		if IsAnonymous(c) {
+			hidePrivateReferences(&item)
+		}
 		itemDetails[i].FromDB(&item)
 	}
 
@@ -147,20 +152,45 @@ func GetItem(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
-	preloads := []string{"Category", "Tags", "Collections", "Values", "Values.Field"}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
+	if IsAnonymous(c) {
+		uniqueFields["private"] = false
+	}
+	preloads := []string{"Category", "Tags", "Collections", "Aliases", "Values", "Values.Field", "Values.Option", "Values.Ref", "Category.Fields", "Category.Fields.Options"}
 	item, err := dao.Get[model.Item](conn.GetDB(), uniqueFields, preloads...)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
+	if IsAnonymous(c) {
+		hidePrivateReferences(&item)
+	}
+
+	references, err := service.ListItemReferences(item.ID, IsAnonymous(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
 
 	itemDetail := define.ItemDetail{}
 	itemDetail.FromDB(&item)
+	itemDetail.ReferencedBy = make([]define.ItemReference, len(references))
+	for i, reference := range references {
+		itemDetail.ReferencedBy[i].FromDB(&reference)
+	}
 
 	SuccessWithData(c, itemDetail)
 }
 
+// hidePrivateReferences 匿名访问时隐藏关联字段中的私密藏品
+func hidePrivateReferences(item *model.Item) {
+	for i := range item.Values {
+		if ref := item.Values[i].Ref; ref != nil && ref.Private {
+			item.Values[i].Ref = nil
+		}
+	}
+}
+
 func AddTag(c *gin.Context) {
 	itemID, err := GetID(c, "id")
 	if err != nil {
@@ -174,7 +204,7 @@ func AddTag(c *gin.Context) {
 		return
 	}
 
-	err = dao.Associate[model.Item, model.Tag](conn.GetDB(), itemID, tagID, "Tags")
+	err = service.AddItemTag(itemID, tagID, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -196,7 +226,7 @@ func RemoveTag(c *gin.Context) {
 		return
 	}
 
-	err = dao.Disassociate[model.Item, model.Tag](conn.GetDB(), itemID, tagID, "Tags")
+	err = service.RemoveItemTag(itemID, tagID, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -218,7 +248,7 @@ func AddToCollection(c *gin.Context) {
 		return
 	}
 
-	err = dao.Associate[model.Item, model.Collection](conn.GetDB(), itemID, collectionID, "Collections")
+	err = dao.Associate[model.Item, model.Collection](conn.GetDB(), itemID, collectionID, "Collections", GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -240,7 +270,7 @@ func RemoveFromCollection(c *gin.Context) {
 		return
 	}
 
-	err = dao.Disassociate[model.Item, model.Collection](conn.GetDB(), itemID, collectionID, "Collections")
+	err = dao.Disassociate[model.Item, model.Collection](conn.GetDB(), itemID, collectionID, "Collections", GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -8,6 +8,8 @@ import (
 	model "collectify/internal/model/db"
 	define "collectify/internal/model/define"
 	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/normalize"
+	"collectify/internal/service"
 	"errors"
 
 	"github.com/gin-gonic/gin"
@@ -21,25 +23,25 @@ func CreateTag(c *gin.Context) {
 		return
 	}
 
+	userID := GetOwnerID(c)
+
 	// 检查是否重复
-	uniqueFields := map[string]interface{}{"name": req.Name}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"name": req.Name}, userID)
 	filters := []dao.Filter{}
 	id, isDeleted, err := dao.DuplicateCheck[model.Tag](conn.GetDB(), uniqueFields, filters)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
-	if id != 0 {
-		FailWithData(c, e.ErrDuplicated, map[string]interface{}{
-			"id":        id,
-			"isDeleted": isDeleted,
-		})
+	if !ResolveDuplicate(c, id, isDeleted, req.OnDeleted, service.RestoreTag) {
 		return
 	}
 
 	// 创建
 	tag := &model.Tag{
-		Name: req.Name,
+		UserID:  userID,
+		Name:    req.Name,
+		NameKey: normalize.Key(req.Name),
 	}
 	err = dao.Create(conn.GetDB(), tag)
 	if err != nil {
@@ -58,13 +60,19 @@ func DeleteTag(c *gin.Context) {
 	}
 
 	isSoftDelete := config.GetConfig().RecycleBin.Enable
-	uniqueFields := map[string]interface{}{"id": id}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
 	err = dao.Delete[model.Tag](conn.GetDB(), uniqueFields, isSoftDelete)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
 
+	err = service.IndexTagItems(id)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
 	Success(c)
 }
 
@@ -75,8 +83,7 @@ func RestoreTag(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
-	err = dao.Restore[model.Tag](conn.GetDB(), uniqueFields)
+	err = service.RestoreTag(id, GetOwnerID(c))
 	if err != nil {
 		Fail(c, err)
 		return
@@ -97,13 +104,26 @@ func RenameTag(c *gin.Context) {
 		return
 	}
 
+	userID := GetOwnerID(c)
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": tagID}, userID)
+	tag, err := dao.Get[model.Tag](conn.GetDB(), uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Fail(c, e.ErrNotFound)
+			return
+		}
+		Fail(c, err)
+		return
+	}
+
 	// 检查是否重复
-	uniqueFields := map[string]interface{}{
-		"name": req.Name,
+	uniqueFields = map[string]interface{}{
+		"user_id": tag.UserID,
+		"name":    req.Name,
 	}
 	filters := []dao.Filter{
 		{
-			Where: "id != ?",
+			Where: "id != ? AND deleted_at IS NULL",
 			Args:  []interface{}{tagID},
 		},
 	}
@@ -121,13 +141,19 @@ func RenameTag(c *gin.Context) {
 	}
 
 	uniqueFields = map[string]interface{}{"id": tagID}
-	updateFields := map[string]interface{}{"name": req.Name}
+	updateFields := map[string]interface{}{"name": req.Name, "name_key": normalize.Key(req.Name)}
 	err = dao.Update[model.Tag](conn.GetDB(), uniqueFields, updateFields)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
 
+	err = service.IndexTagItems(tagID)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
 	Success(c)
 }
 
@@ -138,7 +164,7 @@ func GetTag(c *gin.Context) {
 		return
 	}
 
-	uniqueFields := map[string]interface{}{"id": id}
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": id}, GetOwnerID(c))
 	tag, err := dao.Get[model.Tag](conn.GetDB(), uniqueFields)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -158,14 +184,11 @@ func GetTag(c *gin.Context) {
 func ListTag(c *gin.Context) {
 	name := c.Query("name")
 
-	var filters []dao.Filter
+	filters := dao.OwnerFilter("", GetOwnerID(c))
 	var orderBy []dao.OrderBy
 
 	if name != "" {
-		filters = append(filters, dao.Filter{
-			Where: "name LIKE ?",
-			Args:  []interface{}{"%" + name + "%"},
-		})
+		filters = append(filters, dao.NameKeyFilter("name_key", name))
 	}
 
 	// 不分页
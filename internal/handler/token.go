@@ -0,0 +1,74 @@
+package handler
+
+import (
+	define "collectify/internal/model/define"
+	"collectify/internal/pkg/e"
+	"collectify/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListAPITokens(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		Fail(c, e.ErrUnauthorized)
+		return
+	}
+
+	tokens, err := service.ListAPITokens(userID)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	tokenInfos := make([]define.APIToken, len(tokens))
+	for idx, token := range tokens {
+		tokenInfos[idx].FromDB(&token)
+	}
+
+	SuccessWithData(c, define.SearchResp{
+		List:  tokenInfos,
+		Total: int64(len(tokenInfos)),
+	})
+}
+
+func CreateAPIToken(c *gin.Context) {
+	var req define.CreateAPITokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, err)
+		return
+	}
+
+	userID := GetUserID(c)
+	if userID == 0 {
+		Fail(c, e.ErrUnauthorized)
+		return
+	}
+
+	token, plain, err := service.CreateAPIToken(userID, req.Name, req.Scope)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	resp := define.CreateAPITokenResp{Token: plain}
+	resp.APIToken.FromDB(&token)
+
+	SuccessWithData(c, resp)
+}
+
+func DeleteAPIToken(c *gin.Context) {
+	id, err := GetID(c, "id")
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	err = service.DeleteAPIToken(id, GetUserID(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
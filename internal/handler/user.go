@@ -4,15 +4,14 @@ import (
 	"collectify/internal/config"
 	"collectify/internal/conn"
 	"collectify/internal/dao"
+	"collectify/internal/model/common"
 	model "collectify/internal/model/db"
 	"collectify/internal/model/define"
 	e "collectify/internal/pkg/e"
 	"collectify/internal/service"
-	"errors"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"github.com/spf13/cast"
 )
 
 // 检查认证是否启用
@@ -21,6 +20,48 @@ func IsAuthEnabled(c *gin.Context) {
 	SuccessWithData(c, cfg.Auth.Enable)
 }
 
+// 检查是否启用了 OpenID Connect 登录
+func IsOIDCEnabled(c *gin.Context) {
+	SuccessWithData(c, service.OIDCEnabled())
+}
+
+// 获取身份提供方的登录地址
+func OIDCLogin(c *gin.Context) {
+	url, state, err := service.OIDCAuthURL(c.Request.Context())
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	SuccessWithData(c, define.OIDCLoginResp{
+		URL:   url,
+		State: state,
+	})
+}
+
+// 使用身份提供方回调的授权码登录
+func OIDCCallback(c *gin.Context) {
+	var req define.OIDCCallbackReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	user, err := service.OIDCLogin(c.Request.Context(), req.Code, req.State, loginClient(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	// 启用两步验证时需先完成挑战
+	if user.TOTPEnabled {
+		loginChallenge(c, user, service.LoginPurposeToken)
+		return
+	}
+
+	issueLoginToken(c, user)
+}
+
 // 登录
 func UserLogin(c *gin.Context) {
 	cfg := config.GetConfig()
@@ -31,44 +72,265 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	var req define.LoginReq
+	user, ok := authenticate(c)
+	if !ok {
+		return
+	}
+
+	// 启用两步验证时需先完成挑战
+	if user.TOTPEnabled {
+		loginChallenge(c, user, service.LoginPurposeToken)
+		return
+	}
+
+	issueLoginToken(c, user)
+}
+
+// 登录并获取短期访问令牌和刷新令牌
+func UserTokenPair(c *gin.Context) {
+	cfg := config.GetConfig()
+
+	// 如果未启用认证，则直接返回成功
+	if !cfg.Auth.Enable {
+		Success(c)
+		return
+	}
+
+	user, ok := authenticate(c)
+	if !ok {
+		return
+	}
+
+	// 启用两步验证时需先完成挑战
+	if user.TOTPEnabled {
+		loginChallenge(c, user, service.LoginPurposeTokenPair)
+		return
+	}
+
+	pair, err := service.IssueTokenPair(user)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	respondTokenPair(c, user, pair)
+}
+
+// 使用验证码或恢复码完成两步验证，签发登录时请求的凭证
+func UserLoginTOTP(c *gin.Context) {
+	cfg := config.GetConfig()
+
+	// 如果未启用认证，则直接返回成功
+	if !cfg.Auth.Enable {
+		Success(c)
+		return
+	}
+
+	var req define.LoginTOTPReq
 	if err := c.ShouldBind(&req); err != nil {
 		Fail(c, e.ErrInvalidParams)
 		return
 	}
 
-	db := conn.GetDB()
-	var user model.User
-	err := db.Where("username = ?", req.Username).First(&user).Error
+	user, purpose, err := service.VerifyLoginChallenge(req.Challenge, req.Code, loginClient(c))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			Fail(c, e.ErrUserNotFound)
+		Fail(c, err)
+		return
+	}
+
+	if purpose == service.LoginPurposeTokenPair {
+		pair, err := service.IssueTokenPair(user)
+		if err != nil {
+			Fail(c, err)
 			return
 		}
-		Fail(c, err)
+		respondTokenPair(c, user, pair)
 		return
 	}
 
-	if user.Password != req.Password {
-		Fail(c, e.ErrUserInvalidPassword)
+	issueLoginToken(c, user)
+}
+
+func loginChallenge(c *gin.Context, user model.User, purpose string) {
+	challenge, err := service.NewLoginChallenge(user, purpose)
+	if err != nil {
+		Fail(c, err)
 		return
 	}
 
-	expireTime := time.Duration(cfg.Auth.ExpireDay) * time.Hour * 24
-	token, err := service.GenerateToken(user, expireTime)
+	SuccessWithData(c, define.LoginChallengeResp{
+		TOTPRequired: true,
+		Challenge:    challenge,
+	})
+}
+
+func issueLoginToken(c *gin.Context, user model.User) {
+	token, err := service.IssueLoginToken(user)
 	if err != nil {
 		Fail(c, err)
 		return
 	}
 
 	SuccessWithData(c, define.LoginResp{
-		Token:    token,
-		ID:       user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		Token:              token,
+		ID:                 user.ID,
+		Username:           user.Username,
+		Role:               user.Role,
+		MustChangePassword: user.MustChangePassword,
+	})
+}
+
+func respondTokenPair(c *gin.Context, user model.User, pair service.TokenPair) {
+	SuccessWithData(c, define.TokenPairResp{
+		AccessToken:        pair.AccessToken,
+		RefreshToken:       pair.RefreshToken,
+		ExpiresIn:          int64(pair.ExpiresIn.Seconds()),
+		ID:                 user.ID,
+		Username:           user.Username,
+		Role:               user.Role,
+		MustChangePassword: user.MustChangePassword,
 	})
 }
 
+// 使用刷新令牌换取新的令牌对
+func UserRefreshToken(c *gin.Context) {
+	cfg := config.GetConfig()
+
+	// 如果未启用认证，则直接返回成功
+	if !cfg.Auth.Enable {
+		Success(c)
+		return
+	}
+
+	var req define.RefreshTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	user, pair, err := service.RefreshTokenPair(req.RefreshToken)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	respondTokenPair(c, user, pair)
+}
+
+// 退出登录，吊销当前会话，all 为 true 时吊销该用户的所有会话
+func UserLogout(c *gin.Context) {
+	cfg := config.GetConfig()
+
+	// 如果未启用认证，则直接返回成功
+	if !cfg.Auth.Enable {
+		Success(c)
+		return
+	}
+
+	var err error
+	if cast.ToBool(c.Query("all")) {
+		err = service.RevokeUserSessions(GetUserID(c), 0)
+	} else {
+		err = service.RevokeSession(c.GetUint("session_id"), GetUserID(c))
+	}
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
+// 校验用户名和密码，失败时直接返回错误
+func authenticate(c *gin.Context) (model.User, bool) {
+	var req define.LoginReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return model.User{}, false
+	}
+
+	user, err := service.Login(req.Username, req.Password, loginClient(c))
+	if err != nil {
+		Fail(c, err)
+		return model.User{}, false
+	}
+	return user, true
+}
+
+func loginClient(c *gin.Context) service.LoginClient {
+	return service.LoginClient{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// 生成待确认的验证器密钥
+func SetupTOTP(c *gin.Context) {
+	secret, uri, err := service.SetupTOTP(GetUserID(c))
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	SuccessWithData(c, define.TOTPSetupResp{
+		Secret: secret,
+		URI:    uri,
+	})
+}
+
+// 确认验证码并启用两步验证
+func EnableTOTP(c *gin.Context) {
+	var req define.EnableTOTPReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	codes, err := service.EnableTOTP(GetUserID(c), req.Code)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	SuccessWithData(c, define.TOTPRecoveryCodesResp{
+		RecoveryCodes: codes,
+	})
+}
+
+// 停用两步验证
+func DisableTOTP(c *gin.Context) {
+	var req define.DisableTOTPReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	err := service.DisableTOTP(GetUserID(c), req.CurrentPassword, req.Code)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
+// 管理员重置用户的两步验证
+func ResetUserTOTP(c *gin.Context) {
+	id, err := GetID(c, "id")
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	err = service.ResetTOTP(id)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
 func UserUpdate(c *gin.Context) {
 	cfg := config.GetConfig()
 
@@ -84,6 +346,12 @@ func UserUpdate(c *gin.Context) {
 		return
 	}
 
+	// 只能修改当前登录用户
+	if req.ID != GetUserID(c) {
+		Fail(c, e.ErrUnauthorized)
+		return
+	}
+
 	oldUsername := c.GetString("user_username")
 
 	// 如果旧用户名和新用户名不同，则检查是否重复
@@ -104,17 +372,147 @@ func UserUpdate(c *gin.Context) {
 		}
 	}
 
-	uniqueFields := map[string]interface{}{"id": req.ID}
-	updateFields := map[string]interface{}{
-		"username": req.Username,
+	err := service.UpdateUser(req.ID, c.GetUint("session_id"), req.Username, req.CurrentPassword, req.Password)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
+func ListUsers(c *gin.Context) {
+	filters := []dao.Filter{}
+	orderBy := []dao.OrderBy{
+		{
+			Column: "id",
+			Desc:   false,
+		},
+	}
+	pagination := common.Pagination{
+		Disable: true,
+	}
+	users, total, err := dao.GetList[model.User](conn.GetDB(), filters, orderBy, pagination)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	userInfos := make([]define.User, len(users))
+	for idx, user := range users {
+		userInfos[idx].FromDB(&user)
+	}
+
+	SuccessWithData(c, define.SearchResp{
+		List:  userInfos,
+		Total: total,
+	})
+}
+
+func CreateUser(c *gin.Context) {
+	var req define.CreateUserReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, err)
+		return
 	}
 
-	// 如果密码不为空，则更新密码
-	if req.Password != "" {
-		updateFields["password"] = req.Password
+	// 检查是否重复
+	uniqueFields := map[string]interface{}{"username": req.Username}
+	filters := []dao.Filter{}
+	id, isDeleted, err := dao.DuplicateCheck[model.User](conn.GetDB(), uniqueFields, filters)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+	if id != 0 {
+		FailWithData(c, e.ErrDuplicated, map[string]interface{}{
+			"id":        id,
+			"isDeleted": isDeleted,
+		})
+		return
+	}
+
+	user, err := service.CreateUser(req.Username, req.Password, req.Role, req.LibraryID)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	userInfo := define.User{}
+	userInfo.FromDB(&user)
+
+	SuccessWithData(c, userInfo)
+}
+
+func UpdateUserRole(c *gin.Context) {
+	id, err := GetID(c, "id")
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	var req define.UpdateUserRoleReq
+	if err := c.ShouldBind(&req); err != nil {
+		Fail(c, e.ErrInvalidParams)
+		return
+	}
+
+	err = service.SetUserRole(id, req.Role)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	Success(c)
+}
+
+// 查询登录审计日志
+func ListLoginAudits(c *gin.Context) {
+	pagination, err := GetPagination(c)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	audits, total, err := service.ListLoginAudits(c.Query("username"), c.Query("ip"), c.Query("result"), pagination)
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	auditInfos := make([]define.LoginAudit, len(audits))
+	for idx, audit := range audits {
+		auditInfos[idx].FromDB(&audit)
+	}
+
+	SuccessWithData(c, define.SearchResp{
+		List:  auditInfos,
+		Total: total,
+	})
+}
+
+func DisableUser(c *gin.Context) {
+	setUserDisabled(c, true)
+}
+
+func EnableUser(c *gin.Context) {
+	setUserDisabled(c, false)
+}
+
+func setUserDisabled(c *gin.Context, disabled bool) {
+	id, err := GetID(c, "id")
+	if err != nil {
+		Fail(c, err)
+		return
+	}
+
+	// 不能停用自己
+	if disabled && id == GetUserID(c) {
+		Fail(c, e.ErrForbidden)
+		return
 	}
 
-	err := dao.Update[model.User](conn.GetDB(), uniqueFields, updateFields)
+	err = service.SetUserDisabled(id, disabled)
 	if err != nil {
 		Fail(c, err)
 		return
@@ -2,47 +2,199 @@ package middleware
 
 import (
 	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
 	"collectify/internal/handler"
+	model "collectify/internal/model/db"
 	"collectify/internal/pkg/e"
+	"collectify/internal/service"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cast"
 )
 
+const bearerPrefix = "Bearer "
+
+// AuthCheck 校验 token，需要修改初始密码的用户无法访问
 func AuthCheck(c *gin.Context) {
+	authCheck(c, false)
+}
+
+// PasswordChangeAuthCheck 校验 token，允许需要修改初始密码的用户访问，仅用于修改密码的路由
+func PasswordChangeAuthCheck(c *gin.Context) {
+	authCheck(c, true)
+}
+
+// OptionalAuthCheck 携带 token 时校验并设置当前用户，未携带时以匿名身份访问
+// 私有实例不允许匿名访问
+func OptionalAuthCheck(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" && !config.GetConfig().Auth.Private {
+		if setPublicOwner(c) {
+			c.Next()
+		}
+		return
+	}
+	authCheck(c, false)
+}
+
+// setPublicOwner 匿名访问和未启用认证时访问公开的藏品库，失败时中止请求并返回 false
+func setPublicOwner(c *gin.Context) bool {
+	ownerID, err := service.PublicOwnerID()
+	if err != nil {
+		handler.Fail(c, err)
+		c.Abort()
+		return false
+	}
+	c.Set("owner_id", ownerID)
+	return true
+}
+
+// PermissionCheck 校验当前用户的角色是否拥有指定权限，需在 AuthCheck 或 OptionalAuthCheck 之后使用
+// 匿名访问由前置的认证中间件决定是否放行，此处不做限制
+func PermissionCheck(permission int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 如果未启用认证，则直接跳过
+		if !config.GetConfig().Auth.Enable {
+			c.Next()
+			return
+		}
+
+		if _, ok := c.Get("user_id"); !ok {
+			c.Next()
+			return
+		}
+
+		if !model.HasPermission(c.GetInt("user_role"), permission) {
+			handler.Fail(c, e.ErrForbidden)
+			c.Abort()
+			return
+		}
+
+		// 个人访问令牌还受作用域限制
+		if scope, ok := c.Get("api_token_scope"); ok && !model.ScopeHasPermission(scope.(string), permission) {
+			handler.Fail(c, e.ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionOnlyCheck 仅允许通过登录获得的 token 访问，个人访问令牌不能管理令牌或修改密码，需在认证中间件之后使用
+func SessionOnlyCheck(c *gin.Context) {
+	if _, ok := c.Get("api_token_id"); ok {
+		handler.Fail(c, e.ErrForbidden)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func authCheck(c *gin.Context, allowPasswordChange bool) {
 	// 如果未启用认证，则直接跳过
 	if !config.GetConfig().Auth.Enable {
+		if setPublicOwner(c) {
+			c.Next()
+		}
+		return
+	}
+
+	// 已通过其他认证中间件校验
+	if _, ok := c.Get("user_id"); ok {
+		if c.GetBool("user_must_change_password") && !allowPasswordChange {
+			handler.Fail(c, e.ErrPasswordChangeRequired)
+			c.Abort()
+			return
+		}
 		c.Next()
 		return
 	}
 
-	// 获取 token
+	// 获取 token，兼容 Bearer 前缀
 	tokenString := c.GetHeader("Authorization")
+	if len(tokenString) > len(bearerPrefix) && strings.EqualFold(tokenString[:len(bearerPrefix)], bearerPrefix) {
+		tokenString = tokenString[len(bearerPrefix):]
+	}
 	if tokenString == "" {
 		handler.Fail(c, e.ErrUnauthorized)
 		c.Abort()
 		return
 	}
 
-	// 解析 token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GetConfig().Auth.JwtSecret), nil
-	})
-	if err != nil {
+	var user model.User
+	if service.IsAPIToken(tokenString) {
+		apiToken, tokenUser, err := service.AuthenticateAPIToken(tokenString)
+		if err != nil {
+			handler.Fail(c, e.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		user = tokenUser
+		c.Set("api_token_id", apiToken.ID)
+		c.Set("api_token_scope", apiToken.Scope)
+	} else {
+		tokenUser, sessionID, err := parseJWT(tokenString)
+		if err != nil {
+			handler.Fail(c, e.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		user = tokenUser
+		c.Set("session_id", sessionID)
+	}
+
+	if user.Disabled {
 		handler.Fail(c, e.ErrUnauthorized)
 		c.Abort()
 		return
 	}
 
-	// 验证 token
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		c.Set("user_id", claims["id"])
-		c.Set("user_username", claims["username"])
-		c.Set("user_role", claims["role"])
-		c.Next()
+	if user.MustChangePassword && !allowPasswordChange {
+		handler.Fail(c, e.ErrPasswordChangeRequired)
+		c.Abort()
 		return
 	}
 
-	handler.Fail(c, e.ErrUnauthorized)
-	c.Abort()
+	c.Set("user_id", user.ID)
+	c.Set("user_username", user.Username)
+	c.Set("user_role", user.Role)
+	c.Set("owner_id", user.OwnerID())
+	c.Set("user_must_change_password", user.MustChangePassword)
+	c.Next()
+}
+
+// 解析 JWT，校验绑定的会话未被吊销，并获取最新的用户信息
+func parseJWT(tokenString string) (model.User, uint, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.GetConfig().Auth.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return model.User{}, 0, err
+	}
+
+	// 验证 token
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return model.User{}, 0, e.ErrUnauthorized
+	}
+
+	// 两步验证挑战等其他用途的 token 不能用于访问
+	if _, ok := claims["typ"]; ok {
+		return model.User{}, 0, e.ErrUnauthorized
+	}
+
+	userID := cast.ToUint(claims["id"])
+	sessionID := cast.ToUint(claims["sid"])
+	if err := service.CheckSession(sessionID, userID); err != nil {
+		return model.User{}, 0, err
+	}
+
+	uniqueFields := map[string]interface{}{"id": userID}
+	user, err := dao.Get[model.User](conn.GetDB(), uniqueFields)
+	if err != nil {
+		return model.User{}, 0, err
+	}
+	return user, sessionID, nil
 }
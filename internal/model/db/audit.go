@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 登录审计结果
+const (
+	LoginResultSuccess   = "success"   // 登录成功
+	LoginResultFailure   = "failure"   // 用户名或密码错误、用户已停用
+	LoginResultLocked    = "locked"    // 尝试次数过多被锁定，未校验密码
+	LoginResultChallenge = "challenge" // 密码正确，等待两步验证
+)
+
+// LoginAudit 登录审计日志，仅追加，不支持软删除
+type LoginAudit struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UserID    uint      `gorm:"not null;default:0;index" json:"user_id"` // 登录成功或用户存在时记录
+	Username  string    `gorm:"not null;index" json:"username"`          // 尝试登录的用户名
+	IP        string    `gorm:"not null;index" json:"ip"`                // 客户端 IP
+	UserAgent string    `json:"user_agent"`                              // 客户端 User-Agent
+	Result    string    `gorm:"not null;index" json:"result"`            // 结果
+	Reason    string    `json:"reason"`                                  // 失败原因
+}
+
+func (a LoginAudit) TableName() string {
+	return "login_audits"
+}
+
+func (a LoginAudit) GetID() uint {
+	return a.ID
+}
+
+func (a LoginAudit) IsDeleted() bool {
+	return false
+}
@@ -5,7 +5,9 @@ import "gorm.io/gorm"
 // Category 类别
 type Category struct {
 	gorm.Model
-	Name string `gorm:"uniqueIndex;not null" json:"name"` // 类别名称唯一
+	UserID  uint   `gorm:"not null;default:0;index;uniqueIndex:idx_category_user_name_live,where:deleted_at IS NULL" json:"user_id"` // 所有者
+	Name    string `gorm:"not null;uniqueIndex:idx_category_user_name_live" json:"name"`                                             // 类别名称在所有者的未删除记录中唯一
+	NameKey string `gorm:"not null;default:''" json:"-"`                                                                             // 名称的搜索键，见 normalize.Key
 
 	// 反向关联
 	Items  []Item  `gorm:"foreignKey:CategoryID"` // 使用该类别的藏品
@@ -5,8 +5,10 @@ import "gorm.io/gorm"
 // Collection 收藏夹
 type Collection struct {
 	gorm.Model
-	Name        string `gorm:"not null;index" json:"name"` // 收藏夹名称
-	Description string `json:"description"`                // 描述
+	UserID      uint   `gorm:"not null;default:0;index" json:"user_id"` // 所有者
+	Name        string `gorm:"not null;index" json:"name"`              // 收藏夹名称
+	NameKey     string `gorm:"not null;default:''" json:"-"`            // 名称的搜索键，见 normalize.Key
+	Description string `json:"description"`                             // 描述
 
 	// 关联的藏品
 	Items []Item `gorm:"many2many:collection_items;" json:"items"` // 包含的藏品（可跨类型）
@@ -13,3 +13,9 @@ const (
 	ModelTypeTag        = "tag"
 	ModelTypeIFV        = "item_field_value"
 )
+
+// 多对多关联表
+const (
+	JoinTableItemTags        = "item_tags"
+	JoinTableCollectionItems = "collection_items"
+)
@@ -11,23 +11,36 @@ const (
 	FieldTypeInt
 	FieldTypeBool
 	FieldTypeDatetime
+	FieldTypeSelect    // 单选，IsArray 为 true 时为多选
+	FieldTypeFloat     // 小数，可设置单位和精度
+	FieldTypeReference // 关联其他收藏品，IsArray 为 true 时可关联多个
 )
 
 var FieldTypeNames = map[int]string{
-	FieldTypeString:   "string",
-	FieldTypeInt:      "int",
-	FieldTypeBool:     "bool",
-	FieldTypeDatetime: "datetime",
+	FieldTypeString:    "string",
+	FieldTypeInt:       "int",
+	FieldTypeBool:      "bool",
+	FieldTypeDatetime:  "datetime",
+	FieldTypeSelect:    "select",
+	FieldTypeFloat:     "float",
+	FieldTypeReference: "reference",
 }
 
 // Field 字段
 type Field struct {
 	gorm.Model
-	CategoryID uint   `gorm:"index;uniqueIndex:idx_field_category_name"`
-	Name       string `gorm:"not null;uniqueIndex:idx_field_category_name"`
+	UserID     uint   `gorm:"not null;default:0;index"`                                                // 所有者
+	CategoryID uint   `gorm:"index;uniqueIndex:idx_field_category_name_live,where:deleted_at IS NULL"` // 同一类别下字段名在未删除记录中唯一
+	Name       string `gorm:"not null;uniqueIndex:idx_field_category_name_live"`
 	Type       int    `gorm:"not null"`
 	IsArray    bool   `gorm:"default:false"`
 	Required   bool   `gorm:"default:false"`
+	Unit       string // 小数字段的单位，如 kg、h、元，仅用于显示
+	Precision  *int   // 小数字段保留的小数位数，为空时不做舍入
+
+	TargetCategoryID *uint // 关联字段只能关联该分类下的收藏品，为空时不限制
+
+	Options []FieldOption `gorm:"foreignKey:FieldID"` // 选择字段的可选项
 }
 
 func (f Field) TableName() string {
@@ -42,6 +55,29 @@ func (f Field) IsDeleted() bool {
 	return f.DeletedAt.Valid
 }
 
+// FieldOption 选择字段的可选项，字段值引用选项 ID，修改选项名称不影响已有的值
+type FieldOption struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FieldID   uint   `gorm:"not null;index"`
+	Value     string `gorm:"not null"` // 选项名称，同一字段下唯一
+	Color     string // 显示颜色，如 #ff0000
+	Sort      int    `gorm:"not null;default:0"` // 显示顺序
+}
+
+func (o FieldOption) TableName() string {
+	return "field_options"
+}
+
+func (o FieldOption) GetID() uint {
+	return o.ID
+}
+
+func (o FieldOption) IsDeleted() bool {
+	return false
+}
+
 // ItemFieldValue 自定义字段值
 type ItemFieldValue struct {
 	gorm.Model
@@ -52,9 +88,14 @@ type ItemFieldValue struct {
 	ValueInt    *int       `gorm:"index"`
 	ValueBool   *bool      `gorm:"index"`
 	ValueTime   *time.Time `gorm:"index"`
+	ValueOption *uint      `gorm:"index"` // 选择字段的选项 ID
+	ValueFloat  *float64   `gorm:"index"`
+	ValueItem   *uint      `gorm:"index"` // 关联字段的收藏品 ID
 
-	Item  Item  `gorm:"foreignKey:ItemID"`
-	Field Field `gorm:"foreignKey:FieldID"`
+	Item   Item         `gorm:"foreignKey:ItemID"`
+	Field  Field        `gorm:"foreignKey:FieldID"`
+	Option *FieldOption `gorm:"foreignKey:ValueOption"`
+	Ref    *Item        `gorm:"foreignKey:ValueItem"`
 }
 
 func (i ItemFieldValue) TableName() string {
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// UserIdentity 外部身份提供方（OpenID Connect）账号与本地用户的绑定关系
+type UserIdentity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`                                 // 绑定的本地用户
+	Issuer    string    `gorm:"not null;uniqueIndex:idx_user_identity_subject" json:"issuer"`  // 身份提供方地址
+	Subject   string    `gorm:"not null;uniqueIndex:idx_user_identity_subject" json:"subject"` // 身份提供方中的用户标识（sub）
+}
+
+func (i UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (i UserIdentity) GetID() uint {
+	return i.ID
+}
+
+func (i UserIdentity) IsDeleted() bool {
+	return false
+}
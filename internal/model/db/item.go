@@ -14,10 +14,19 @@ const (
 	ItemStatusCompleted             // 完成
 )
 
+const (
+	ItemAliasTypeOriginal   = "original"   // 原名
+	ItemAliasTypeRomanized  = "romanized"  // 罗马字、拼音等转写
+	ItemAliasTypeTranslated = "translated" // 译名
+	ItemAliasTypeOther      = "other"      // 其他
+)
+
 // Item 收藏品
 type Item struct {
 	gorm.Model
+	UserID      uint       `gorm:"not null;default:0;index" json:"user_id"`                        // 所有者
 	Name        string     `gorm:"not null;index" json:"name"`                                     // 名称
+	NameKey     string     `gorm:"not null;default:''" json:"-"`                                   // 名称的搜索键，见 normalize.Key
 	CategoryID  uint       `gorm:"not null;index" json:"category_id"`                              // 关联的类别ID
 	Status      int        `gorm:"not null;default:1;index" json:"status"`                         // 状态
 	Rating      *float64   `gorm:"type:decimal(3,1);check:rating>=0 and rating<=10" json:"rating"` // 评分
@@ -27,12 +36,16 @@ type Item struct {
 	SourceURL   string     `json:"source_url"`                                                     // 外部链接
 	CompletedAt *time.Time `json:"completed_at"`                                                   // 完成时间
 	Priority    int        `gorm:"default:0" json:"priority"`                                      // 优先级
+	Private     bool       `gorm:"not null;default:false;index" json:"private"`                    // 私密，匿名访问时不可见
 
 	// 关联关系
 	Category    Category         `gorm:"foreignKey:CategoryID"`                          // 所属类别
 	Tags        []Tag            `gorm:"many2many:item_tags;" json:"tags"`               // 多个标签
 	Collections []Collection     `gorm:"many2many:collection_items;" json:"collections"` // 所属的收藏夹
 	Values      []ItemFieldValue `gorm:"foreignKey:ItemID"`                              // 自定义字段值
+	Aliases     []ItemAlias      `gorm:"foreignKey:ItemID"`                              // 别名
+
+	Snippet string `gorm:"-" json:"-"` // 全文搜索时匹配内容的摘要，不保存
 }
 
 func (i Item) TableName() string {
@@ -46,3 +59,27 @@ func (i Item) GetID() uint {
 func (i Item) IsDeleted() bool {
 	return i.DeletedAt.Valid
 }
+
+// ItemAlias 收藏品的别名，如原名、罗马字和译名，按名称搜索时一并匹配
+type ItemAlias struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ItemID    uint   `gorm:"not null;index"`         // 所属的收藏品ID
+	Name      string `gorm:"not null;index"`         // 别名
+	NameKey   string `gorm:"not null;default:''"`    // 别名的搜索键，见 normalize.Key
+	Type      string `gorm:"not null;default:other"` // 别名类型
+	Sort      int    `gorm:"not null;default:0"`     // 显示顺序
+}
+
+func (a ItemAlias) TableName() string {
+	return "item_aliases"
+}
+
+func (a ItemAlias) GetID() uint {
+	return a.ID
+}
+
+func (a ItemAlias) IsDeleted() bool {
+	return false
+}
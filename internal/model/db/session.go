@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session 登录会话，签发的 JWT 与会话绑定，删除会话即吊销对应的 JWT 和刷新令牌
+type Session struct {
+	gorm.Model
+	UserID      uint       `gorm:"not null;index" json:"user_id"` // 所属用户
+	RefreshHash string     `gorm:"index" json:"-"`                // 刷新令牌的 SHA-256 哈希，为空时不支持刷新
+	ExpiresAt   *time.Time `json:"expires_at"`                    // 过期时间，为空时永不过期
+}
+
+func (s Session) TableName() string {
+	return "sessions"
+}
+
+func (s Session) GetID() uint {
+	return s.ID
+}
+
+func (s Session) IsDeleted() bool {
+	return s.DeletedAt.Valid
+}
+
+// IsExpired 判断会话是否已过期
+func (s Session) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
@@ -5,7 +5,9 @@ import "gorm.io/gorm"
 // Tag 标签
 type Tag struct {
 	gorm.Model
-	Name string `gorm:"uniqueIndex;not null" json:"name"` // 标签名唯一
+	UserID  uint   `gorm:"not null;default:0;index;uniqueIndex:idx_tag_user_name_live,where:deleted_at IS NULL" json:"user_id"` // 所有者
+	Name    string `gorm:"not null;uniqueIndex:idx_tag_user_name_live" json:"name"`                                             // 标签名在所有者的未删除记录中唯一
+	NameKey string `gorm:"not null;default:''" json:"-"`                                                                        // 名称的搜索键，见 normalize.Key
 
 	// 反向关联
 	Items []Item `gorm:"many2many:item_tags;"` // 使用该标签的藏品
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIToken 作用域
+const (
+	APITokenScopeRead  = "read"  // 仅可浏览和搜索
+	APITokenScopeWrite = "write" // 可浏览、搜索和修改
+)
+
+// APIToken 个人访问令牌，用于脚本等长期访问
+type APIToken struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index" json:"user_id"`      // 所属用户
+	Name       string     `gorm:"not null" json:"name"`               // 名称
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`      // 令牌的 SHA-256 哈希
+	Prefix     string     `gorm:"not null" json:"prefix"`             // 令牌前缀，用于辨认令牌
+	Scope      string     `gorm:"not null;default:read" json:"scope"` // 作用域
+	LastUsedAt *time.Time `json:"last_used_at"`                       // 最后使用时间
+}
+
+func (t APIToken) TableName() string {
+	return "api_tokens"
+}
+
+func (t APIToken) GetID() uint {
+	return t.ID
+}
+
+func (t APIToken) IsDeleted() bool {
+	return t.DeletedAt.Valid
+}
+
+// ScopeHasPermission 判断令牌作用域是否允许指定权限，写权限包含读权限
+// 个人访问令牌不能用于清空回收站和管理用户
+func ScopeHasPermission(scope string, permission int) bool {
+	switch scope {
+	case APITokenScopeWrite:
+		return permission == PermissionRead || permission == PermissionWrite
+	case APITokenScopeRead:
+		return permission == PermissionRead
+	}
+	return false
+}
@@ -3,14 +3,54 @@ package model
 import "gorm.io/gorm"
 
 const (
-	UserRoleAdmin = iota + 1 // 管理员
+	UserRoleAdmin  = iota + 1 // 管理员
+	UserRoleEditor            // 编辑者，可以管理藏品，不能管理用户和清空回收站
+	UserRoleViewer            // 访客，仅可浏览和搜索
+)
+
+const (
+	PermissionRead       = iota + 1 // 浏览、搜索
+	PermissionWrite                 // 创建、修改、删除、恢复
+	PermissionPurge                 // 彻底删除回收站中的记录
+	PermissionManageUser            // 管理用户
+)
+
+var rolePermissions = map[int][]int{
+	UserRoleAdmin:  {PermissionRead, PermissionWrite, PermissionPurge, PermissionManageUser},
+	UserRoleEditor: {PermissionRead, PermissionWrite},
+	UserRoleViewer: {PermissionRead},
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role, permission int) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// 初始管理员账号，首次登录后必须修改密码
+const (
+	DefaultAdminUsername = "admin"
+	DefaultAdminPassword = "admin"
 )
 
 type User struct {
 	gorm.Model
-	Username string `gorm:"not null;unique" json:"username"`
-	Password string `gorm:"not null" json:"password"`
-	Role     int    `gorm:"not null;default:1" json:"role"`
+	Username           string `gorm:"not null;unique" json:"username"`
+	Password           string `gorm:"not null" json:"-"` // bcrypt 哈希
+	Role               int    `gorm:"not null;default:1" json:"role"`
+	LibraryID          uint   `gorm:"not null;default:0;index" json:"library_id"`         // 共享藏品库的所有者，为 0 时使用自己的藏品库
+	MustChangePassword bool   `gorm:"not null;default:false" json:"must_change_password"` // 是否需要修改密码后才能使用
+	Disabled           bool   `gorm:"not null;default:false" json:"disabled"`             // 是否已停用
+
+	// 两步验证
+	TOTPSecret        string `json:"-"`                                          // 验证器密钥，启用前为待确认的密钥
+	TOTPEnabled       bool   `gorm:"not null;default:false" json:"totp_enabled"` // 是否已启用两步验证
+	TOTPLastCounter   int64  `gorm:"not null;default:0" json:"-"`                // 最后使用的验证码时间步，防止重复使用
+	TOTPRecoveryCodes string `json:"-"`                                          // 未使用的恢复码的 SHA-256 哈希，逗号分隔
 }
 
 func (u User) TableName() string {
@@ -24,3 +64,11 @@ func (u User) GetID() uint {
 func (u User) IsDeleted() bool {
 	return u.DeletedAt.Valid
 }
+
+// OwnerID 返回用户访问的藏品库所有者
+func (u User) OwnerID() uint {
+	if u.LibraryID != 0 {
+		return u.LibraryID
+	}
+	return u.ID
+}
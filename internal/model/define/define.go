@@ -1,7 +1,10 @@
 package define
 
 import (
+	"cmp"
 	model "collectify/internal/model/db"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -11,6 +14,7 @@ type ItemFieldValue struct {
 
 	FieldName string `json:"field_name"`
 	FieldType int    `json:"field_type"`
+	FieldUnit string `json:"field_unit,omitempty"`
 }
 
 type Item struct {
@@ -27,12 +31,41 @@ type Item struct {
 	CoverURL    string           `json:"cover_url" form:"cover_url" binding:"omitempty,url"`
 	SourceURL   string           `json:"source_url" form:"source_url" binding:"omitempty,url"`
 	Priority    int              `json:"priority" form:"priority" binding:"omitempty,min=0"`
+	Private     bool             `json:"private" form:"private"`
 	Values      []ItemFieldValue `json:"values" form:"values" binding:"omitempty,dive"`
+	Aliases     []ItemAlias      `json:"aliases" form:"aliases" binding:"omitempty,dive"`
 
 	Category Category `json:"category"`
 }
 
+// ItemAlias 收藏品的别名，类型为空时为 other
+type ItemAlias struct {
+	Name string `json:"name" form:"name" binding:"required,max=200"`
+	Type string `json:"type" form:"type" binding:"omitempty,oneof=original romanized translated other"`
+}
+
+func (a ItemAlias) ToDB(sort int) model.ItemAlias {
+	alias := model.ItemAlias{
+		Name: strings.TrimSpace(a.Name),
+		Type: a.Type,
+		Sort: sort,
+	}
+	if alias.Type == "" {
+		alias.Type = model.ItemAliasTypeOther
+	}
+	return alias
+}
+
+func (a *ItemAlias) FromDB(alias *model.ItemAlias) {
+	a.Name = alias.Name
+	a.Type = alias.Type
+}
+
 func (i Item) ToDB() *model.Item {
+	var aliases []model.ItemAlias
+	for idx, alias := range i.Aliases {
+		aliases = append(aliases, alias.ToDB(idx))
+	}
 	return &model.Item{
 		Name:        i.Name,
 		Status:      i.Status,
@@ -42,6 +75,8 @@ func (i Item) ToDB() *model.Item {
 		CoverURL:    i.CoverURL,
 		SourceURL:   i.SourceURL,
 		Priority:    i.Priority,
+		Private:     i.Private,
+		Aliases:     aliases,
 	}
 }
 
@@ -59,18 +94,52 @@ func (i *Item) FromDB(item *model.Item) {
 	i.CoverURL = item.CoverURL
 	i.SourceURL = item.SourceURL
 	i.Priority = item.Priority
+	i.Private = item.Private
+
+	aliases := slices.Clone(item.Aliases)
+	slices.SortStableFunc(aliases, func(a, b model.ItemAlias) int {
+		return cmp.Or(cmp.Compare(a.Sort, b.Sort), cmp.Compare(a.ID, b.ID))
+	})
+	i.Aliases = make([]ItemAlias, len(aliases))
+	for idx, alias := range aliases {
+		i.Aliases[idx].FromDB(&alias)
+	}
 
 	i.Category.FromDB(&item.Category)
 }
 
 type ItemDetail struct {
 	Item
-	Tags        []Tag        `json:"tags"`
-	Collections []Collection `json:"collections"`
+	Tags         []Tag           `json:"tags"`
+	Collections  []Collection    `json:"collections"`
+	ReferencedBy []ItemReference `json:"referenced_by,omitempty"` // 通过关联字段引用了该收藏品的收藏品
+	Snippet      string          `json:"snippet,omitempty"`       // 全文搜索时匹配内容的摘要，匹配的部分用 <mark></mark> 标记
+}
+
+// ItemRef 关联字段的值
+type ItemRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// ItemReference 通过关联字段引用了某收藏品的收藏品
+type ItemReference struct {
+	ItemID    uint   `json:"item_id"`
+	ItemName  string `json:"item_name"`
+	FieldID   uint   `json:"field_id"`
+	FieldName string `json:"field_name"`
+}
+
+func (r *ItemReference) FromDB(value *model.ItemFieldValue) {
+	r.ItemID = value.ItemID
+	r.ItemName = value.Item.Name
+	r.FieldID = value.FieldID
+	r.FieldName = value.Field.Name
 }
 
 func (i *ItemDetail) FromDB(item *model.Item) {
 	i.Item.FromDB(item)
+	i.Snippet = item.Snippet
 
 	i.Tags = make([]Tag, len(item.Tags))
 	i.Collections = make([]Collection, len(item.Collections))
@@ -104,6 +173,19 @@ func (i *ItemDetail) FromDB(item *model.Item) {
 			return value.ValueBool
 		case model.FieldTypeDatetime:
 			return value.ValueTime
+		case model.FieldTypeFloat:
+			return value.ValueFloat
+		case model.FieldTypeReference:
+			// 关联的收藏品已删除或不可见
+			if value.Ref == nil {
+				return nil
+			}
+			return ItemRef{ID: value.Ref.ID, Name: value.Ref.Name}
+		case model.FieldTypeSelect:
+			if value.Option == nil {
+				return nil
+			}
+			return value.Option.Value
 		default:
 			return nil
 		}
@@ -114,6 +196,7 @@ func (i *ItemDetail) FromDB(item *model.Item) {
 			FieldID:   fieldID,
 			FieldName: values[0].Field.Name,
 			FieldType: values[0].Field.Type,
+			FieldUnit: values[0].Field.Unit,
 		}
 		if fieldIsArray[fieldID] {
 			var valueArray []interface{}
@@ -129,11 +212,16 @@ func (i *ItemDetail) FromDB(item *model.Item) {
 }
 
 type Field struct {
-	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Type     int    `json:"type"`
-	IsArray  bool   `json:"is_array"`
-	Required bool   `json:"required"`
+	ID        uint          `json:"id"`
+	Name      string        `json:"name"`
+	Type      int           `json:"type"`
+	IsArray   bool          `json:"is_array"`
+	Required  bool          `json:"required"`
+	Unit      string        `json:"unit,omitempty"`
+	Precision *int          `json:"precision,omitempty"`
+	Options   []FieldOption `json:"options,omitempty"`
+
+	TargetCategoryID *uint `json:"target_category_id,omitempty"`
 }
 
 func (f *Field) FromDB(field *model.Field) {
@@ -142,6 +230,40 @@ func (f *Field) FromDB(field *model.Field) {
 	f.Type = field.Type
 	f.IsArray = field.IsArray
 	f.Required = field.Required
+	f.Unit = field.Unit
+	f.Precision = field.Precision
+	f.TargetCategoryID = field.TargetCategoryID
+
+	options := slices.Clone(field.Options)
+	slices.SortStableFunc(options, func(a, b model.FieldOption) int {
+		return cmp.Or(cmp.Compare(a.Sort, b.Sort), cmp.Compare(a.ID, b.ID))
+	})
+	f.Options = make([]FieldOption, len(options))
+	for idx, option := range options {
+		f.Options[idx].FromDB(&option)
+	}
+}
+
+// FieldOption 选择字段的可选项，更新时 ID 为 0 表示新增选项
+type FieldOption struct {
+	ID    uint   `json:"id" form:"id"`
+	Value string `json:"value" form:"value" binding:"required"`
+	Color string `json:"color" form:"color" binding:"omitempty,hexcolor"`
+}
+
+func (o FieldOption) ToDB(sort int) model.FieldOption {
+	return model.FieldOption{
+		ID:    o.ID,
+		Value: o.Value,
+		Color: o.Color,
+		Sort:  sort,
+	}
+}
+
+func (o *FieldOption) FromDB(option *model.FieldOption) {
+	o.ID = option.ID
+	o.Value = option.Value
+	o.Color = option.Color
 }
 
 type Category struct {
@@ -182,3 +304,65 @@ func (c *Collection) FromDB(collection *model.Collection) {
 	c.Name = collection.Name
 	c.Description = collection.Description
 }
+
+type User struct {
+	ID                 uint      `json:"id"`
+	CreatedAt          time.Time `json:"created_at"`
+	Username           string    `json:"username"`
+	Role               int       `json:"role"`
+	LibraryID          uint      `json:"library_id"`
+	MustChangePassword bool      `json:"must_change_password"`
+	Disabled           bool      `json:"disabled"`
+	TOTPEnabled        bool      `json:"totp_enabled"`
+}
+
+func (u *User) FromDB(user *model.User) {
+	u.ID = user.ID
+	u.CreatedAt = user.CreatedAt
+	u.Username = user.Username
+	u.Role = user.Role
+	u.LibraryID = user.LibraryID
+	u.MustChangePassword = user.MustChangePassword
+	u.Disabled = user.Disabled
+	u.TOTPEnabled = user.TOTPEnabled
+}
+
+type APIToken struct {
+	ID         uint       `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (t *APIToken) FromDB(token *model.APIToken) {
+	t.ID = token.ID
+	t.CreatedAt = token.CreatedAt
+	t.Name = token.Name
+	t.Prefix = token.Prefix
+	t.Scope = token.Scope
+	t.LastUsedAt = token.LastUsedAt
+}
+
+type LoginAudit struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Result    string    `json:"result"`
+	Reason    string    `json:"reason"`
+}
+
+func (a *LoginAudit) FromDB(audit *model.LoginAudit) {
+	a.ID = audit.ID
+	a.CreatedAt = audit.CreatedAt
+	a.UserID = audit.UserID
+	a.Username = audit.Username
+	a.IP = audit.IP
+	a.UserAgent = audit.UserAgent
+	a.Result = audit.Result
+	a.Reason = audit.Reason
+}
@@ -1,6 +1,17 @@
 package define
 
-import "time"
+// 存在已删除的同名记录时的处理方式
+const (
+	OnDeletedRestore = "restore" // 恢复已删除的记录
+	OnDeletedCreate  = "create"  // 忽略已删除的记录，创建新记录
+)
+
+// 标签和收藏夹的匹配方式
+const (
+	MatchAny  = "any"  // 关联了任一标签或收藏夹
+	MatchAll  = "all"  // 关联了全部标签或收藏夹
+	MatchNone = "none" // 未关联任何标签或收藏夹
+)
 
 type DeletedReq struct {
 	List []DeletedReqItem `json:"list" form:"list" binding:"required,dive"`
@@ -12,9 +23,75 @@ type ListReq struct {
 	PageSize int  `json:"page_size" form:"page_size"`
 }
 
-type SearchFilterDatetime struct {
-	Start time.Time `json:"start" form:"start"`
-	End   time.Time `json:"end" form:"end"`
+// 字段筛选的比较运算符
+const (
+	FilterOpEq         = "eq"          // 等于
+	FilterOpNe         = "ne"          // 不等于，数组字段中没有任何值等于该值
+	FilterOpLt         = "lt"          // 小于
+	FilterOpLte        = "lte"         // 小于等于
+	FilterOpGt         = "gt"          // 大于
+	FilterOpGte        = "gte"         // 大于等于
+	FilterOpBetween    = "between"     // 在两个值之间，包含边界，值为 [下限, 上限]
+	FilterOpContains   = "contains"    // 包含子串，仅字符串
+	FilterOpStartsWith = "starts_with" // 以该值开头，仅字符串
+	FilterOpIsEmpty    = "is_empty"    // 没有值，值为 false 时表示有值
+	FilterOpIn         = "in"          // 等于数组中的任一值
+)
+
+// FieldFilter 字段筛选条件，数组字段中任一值满足条件即匹配（ne 和 is_empty 除外）
+type FieldFilter struct {
+	Op    string      `json:"op" form:"op"`
+	Value interface{} `json:"value" form:"value"`
+}
+
+// ParseFieldFilter 从 SearchItemsReq.Filters 的值中解析带运算符的筛选条件，不是该形式时返回 false
+// {"min": 1, "max": 2} 形式的范围转换为 between，只有 min 或 max 时转换为 gte 或 lte
+func ParseFieldFilter(value interface{}) (FieldFilter, bool) {
+	switch v := value.(type) {
+	case FieldFilter:
+		return v, true
+	case map[string]interface{}:
+		if op, ok := v["op"].(string); ok {
+			return FieldFilter{Op: op, Value: v["value"]}, true
+		}
+		return parseRangeFilter(v)
+	}
+	return FieldFilter{}, false
+}
+
+// parseRangeFilter 将 {"min": 1, "max": 2} 形式的范围转换为筛选条件，边界均包含，缺省表示不限制
+func parseRangeFilter(m map[string]interface{}) (FieldFilter, bool) {
+	for key := range m {
+		if key != "min" && key != "max" {
+			return FieldFilter{}, false
+		}
+	}
+	low, high := m["min"], m["max"]
+	switch {
+	case low != nil && high != nil:
+		return FieldFilter{Op: FilterOpBetween, Value: []interface{}{low, high}}, true
+	case low != nil:
+		return FieldFilter{Op: FilterOpGte, Value: low}, true
+	case high != nil:
+		return FieldFilter{Op: FilterOpLte, Value: high}, true
+	}
+	return FieldFilter{}, false
+}
+
+// SplitFieldFilters 将 SearchItemsReq.Filters 的值拆分为独立匹配的筛选条件，需要全部满足
+// 值为 [{"op": "contains", "value": "A"}, {"op": "contains", "value": "B"}] 形式的条件列表时，每个条件分别匹配该字段的任一值，
+// 其他形式的值（包括普通数组）作为一个条件
+func SplitFieldFilters(value interface{}) []interface{} {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return []interface{}{value}
+	}
+	for _, v := range list {
+		if _, ok := ParseFieldFilter(v); !ok {
+			return []interface{}{value}
+		}
+	}
+	return list
 }
 
 type DeletedReqItem struct {
@@ -23,7 +100,8 @@ type DeletedReqItem struct {
 }
 
 type CreateCategoryReq struct {
-	Name string `json:"name" form:"name" binding:"required"`
+	Name      string `json:"name" form:"name" binding:"required"`
+	OnDeleted string `json:"on_deleted" form:"on_deleted" binding:"omitempty,oneof=restore create"`
 }
 
 type RenameCategoryReq struct {
@@ -33,9 +111,21 @@ type RenameCategoryReq struct {
 type CreateFieldReq struct {
 	CategoryID uint   `json:"category_id" form:"category_id" binding:"required,gt=0"`
 	Name       string `json:"name" form:"name" binding:"required"`
-	Type       int    `json:"type" form:"type" binding:"required,oneof=1 2 3 4"`
+	Type       int    `json:"type" form:"type" binding:"required,oneof=1 2 3 4 5 6 7"`
 	IsArray    bool   `json:"is_array" form:"is_array"`
 	Required   bool   `json:"required" form:"required"`
+	OnDeleted  string `json:"on_deleted" form:"on_deleted" binding:"omitempty,oneof=restore create"`
+	Unit       string `json:"unit" form:"unit" binding:"max=20"`                           // 小数字段的单位
+	Precision  *int   `json:"precision" form:"precision" binding:"omitempty,min=0,max=10"` // 小数字段保留的小数位数
+
+	TargetCategoryID *uint `json:"target_category_id" form:"target_category_id" binding:"omitempty,gt=0"` // 关联字段限定的分类
+
+	Options []FieldOption `json:"options" form:"options" binding:"omitempty,dive"` // 选择字段的可选项，按顺序显示
+}
+
+// UpdateFieldOptionsReq 更新选择字段的可选项，未列出的选项将被删除
+type UpdateFieldOptionsReq struct {
+	Options []FieldOption `json:"options" form:"options" binding:"required,dive"`
 }
 
 type CreateItemReq struct {
@@ -50,11 +140,20 @@ type UpdateItemReq struct {
 
 type SearchItemsReq struct {
 	ListReq
-	CategoryID    uint                 `json:"category_id" form:"category_id"`
-	Name          string               `json:"name" form:"name"`
-	TagIDs        []uint               `json:"tag_ids" form:"tag_ids"`
-	CollectionIDs []uint               `json:"collection_ids" form:"collection_ids"`
-	Filters       map[uint]interface{} `json:"filters" form:"filters"`
+	CategoryID           uint                 `json:"category_id" form:"category_id"`
+	Name                 string               `json:"name" form:"name"`
+	Text                 string               `json:"text" form:"text"` // 全文搜索名称、简介、感想、标签和字符串字段，未指定排序时按相关度排序
+	TagIDs               []uint               `json:"tag_ids" form:"tag_ids"`
+	TagMatch             string               `json:"tag_match" form:"tag_match" binding:"omitempty,oneof=any all none"` // TagIDs 的匹配方式，默认为 any
+	ExcludeTagIDs        []uint               `json:"exclude_tag_ids" form:"exclude_tag_ids"`                            // 排除关联了任一这些标签的收藏品
+	CollectionIDs        []uint               `json:"collection_ids" form:"collection_ids"`
+	CollectionMatch      string               `json:"collection_match" form:"collection_match" binding:"omitempty,oneof=any all none"` // CollectionIDs 的匹配方式，默认为 any
+	ExcludeCollectionIDs []uint               `json:"exclude_collection_ids" form:"exclude_collection_ids"`                            // 排除在任一这些收藏夹中的收藏品
+	Filters              map[uint]interface{} `json:"filters" form:"filters"`                                                          // 字段 ID 到筛选值，值为 {"op": "gte", "value": 8} 形式时按运算符筛选，多个条件时为条件列表
+	CreatedAt            interface{}          `json:"created_at" form:"created_at"`                                                    // 创建时间范围，格式见 timerange.Parse
+	CompletedAt          interface{}          `json:"completed_at" form:"completed_at"`                                                // 完成时间范围，格式见 timerange.Parse
+	Sort                 string               `json:"sort" form:"sort"`                                                                // 排序，如 -rating,name,field:12，- 表示降序
+	Q                    string               `json:"q" form:"q"`                                                                      // 查询语句，如 tag:scifi AND rating>=8，语法见 query.Parse
 }
 
 type LoginReq struct {
@@ -62,14 +161,55 @@ type LoginReq struct {
 	Password string `json:"password" form:"password" binding:"required"`
 }
 
+type LoginTOTPReq struct {
+	Challenge string `json:"challenge" form:"challenge" binding:"required"`
+	Code      string `json:"code" form:"code" binding:"required"` // 验证码或恢复码
+}
+
+type EnableTOTPReq struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+type DisableTOTPReq struct {
+	CurrentPassword string `json:"current_password" form:"current_password" binding:"required"`
+	Code            string `json:"code" form:"code" binding:"required"` // 验证码或恢复码
+}
+
+type OIDCCallbackReq struct {
+	Code  string `json:"code" form:"code" binding:"required"`   // 身份提供方回调的授权码
+	State string `json:"state" form:"state" binding:"required"` // 获取登录地址时返回的 state
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" binding:"required"`
+}
+
 type UpdateUserReq struct {
-	ID       uint   `json:"id" form:"id" binding:"required,gt=0"`
-	Username string `json:"username" form:"username" binding:"required"`
-	Password string `json:"password" form:"password"`
+	ID              uint   `json:"id" form:"id" binding:"required,gt=0"`
+	Username        string `json:"username" form:"username" binding:"required"`
+	Password        string `json:"password" form:"password" binding:"omitempty,min=6"` // 新密码，为空时不修改
+	CurrentPassword string `json:"current_password" form:"current_password" binding:"required"`
+}
+
+type CreateUserReq struct {
+	Username  string `json:"username" form:"username" binding:"required"`
+	Password  string `json:"password" form:"password" binding:"required,min=6"`
+	Role      int    `json:"role" form:"role" binding:"required,oneof=1 2 3"`
+	LibraryID uint   `json:"library_id" form:"library_id"` // 共享该用户的藏品库，为 0 时使用自己的藏品库
+}
+
+type CreateAPITokenReq struct {
+	Name  string `json:"name" form:"name" binding:"required"`
+	Scope string `json:"scope" form:"scope" binding:"required,oneof=read write"`
+}
+
+type UpdateUserRoleReq struct {
+	Role int `json:"role" form:"role" binding:"required,oneof=1 2 3"`
 }
 
 type CreateTagReq struct {
-	Name string `json:"name" form:"name" binding:"required"`
+	Name      string `json:"name" form:"name" binding:"required"`
+	OnDeleted string `json:"on_deleted" form:"on_deleted" binding:"omitempty,oneof=restore create"`
 }
 
 type RenameTagReq struct {
@@ -79,6 +219,7 @@ type RenameTagReq struct {
 type CreateCollectionReq struct {
 	Name        string `json:"name" form:"name" binding:"required"`
 	Description string `json:"description" form:"description"`
+	OnDeleted   string `json:"on_deleted" form:"on_deleted" binding:"omitempty,oneof=restore create"`
 }
 
 type UpdateCollectionReq struct {
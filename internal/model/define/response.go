@@ -1,13 +1,73 @@
 package define
 
+import "time"
+
 type SearchResp struct {
 	List  interface{} `json:"list"`
 	Total int64       `json:"total"`
 }
 
 type LoginResp struct {
-	Token    string `json:"token"`
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
-	Role     int    `json:"role"`
+	Token              string `json:"token"`
+	ID                 uint   `json:"id"`
+	Username           string `json:"username"`
+	Role               int    `json:"role"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
+// LoginChallengeResp 启用两步验证的用户通过密码校验后，需使用 challenge 和验证码完成登录
+type LoginChallengeResp struct {
+	TOTPRequired bool   `json:"totp_required"`
+	Challenge    string `json:"challenge"`
+}
+
+// OIDCLoginResp 身份提供方的登录地址，前端需保存 state 并在回调时比对
+type OIDCLoginResp struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// TOTPSetupResp 待确认的验证器密钥，uri 可转换为二维码供验证器应用扫描
+type TOTPSetupResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPRecoveryCodesResp 恢复码，仅显示一次
+type TOTPRecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TokenPairResp 短期访问令牌和刷新令牌
+type TokenPairResp struct {
+	AccessToken        string `json:"access_token"`
+	RefreshToken       string `json:"refresh_token"`
+	ExpiresIn          int64  `json:"expires_in"` // 访问令牌有效期（秒），0 表示不过期
+	ID                 uint   `json:"id"`
+	Username           string `json:"username"`
+	Role               int    `json:"role"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
+// CreateAPITokenResp 创建个人访问令牌的结果，明文令牌仅返回这一次
+type CreateAPITokenResp struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// DeletedRecord 回收站中的记录
+type DeletedRecord struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+
+	// 恢复该记录时会一并恢复的其他已删除记录
+	Restores []DeletedRelated `json:"restores"`
+}
+
+type DeletedRelated struct {
+	ID   uint   `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
 }
@@ -39,14 +39,44 @@ var (
 	ErrNotFound = EStruct{
 		err: errors.New("目标不存在"),
 	}
+	ErrNotDeleted = EStruct{
+		err: errors.New("目标不在回收站中"),
+	}
 
 	ErrUnauthorized = EStruct{
 		err: errors.New("未授权"),
 	}
+	ErrForbidden = EStruct{
+		err: errors.New("权限不足"),
+	}
 	ErrUserNotFound = EStruct{
 		err: errors.New("用户不存在"),
 	}
 	ErrUserInvalidPassword = EStruct{
 		err: errors.New("用户名或密码错误"),
 	}
+	ErrUserWrongPassword = EStruct{
+		err: errors.New("当前密码错误"),
+	}
+	ErrPasswordChangeRequired = EStruct{
+		err: errors.New("请先修改初始密码"),
+	}
+	ErrUserDisabled = EStruct{
+		err: errors.New("用户已停用"),
+	}
+	ErrTOTPEnabled = EStruct{
+		err: errors.New("已启用两步验证"),
+	}
+	ErrTOTPNotEnabled = EStruct{
+		err: errors.New("未启用两步验证"),
+	}
+	ErrTOTPInvalidCode = EStruct{
+		err: errors.New("验证码错误"),
+	}
+	ErrOIDCDisabled = EStruct{
+		err: errors.New("未启用 OpenID Connect 登录"),
+	}
+	ErrTooManyAttempts = EStruct{
+		err: errors.New("尝试次数过多，请稍后再试"),
+	}
 )
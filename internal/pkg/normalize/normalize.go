@@ -0,0 +1,82 @@
+// Package normalize 生成名称的搜索键，使名称搜索不区分大小写、全半角和变音符号，并可以用拼音搜索中文
+//
+// 如 "Tólkien" 的搜索键为 "tolkien"，"三体" 的搜索键为 "三体\nsanti\nst"，搜索 "tolkien"、"santi" 或 "st" 都能匹配
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 搜索键中不同形式之间的分隔符，搜索内容经 Fold 后不包含换行，不会跨形式匹配
+const keySeparator = "\n"
+
+// 无法通过分解去除变音符号的字母
+var specialFolds = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ð': "d",
+	'ł': "l",
+	'ħ': "h",
+	'ı': "i",
+	'þ': "th",
+}
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Fold 折叠字符串用于比较：兼容分解后去除变音符号，全角字符转为半角，转为小写，空白（包括换行）转为空格
+//
+// 只去除组合变音符号区（U+0300–U+036F）的符号，保留日文浊音等其他组合符号
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(s) {
+		if r >= 0x0300 && r <= 0x036F {
+			continue
+		}
+		if unicode.IsSpace(r) {
+			b.WriteByte(' ')
+			continue
+		}
+		r = unicode.ToLower(r)
+		if folded, ok := specialFolds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// Key 生成名称的搜索键，包括折叠后的名称，含汉字时还包括全拼和拼音首字母，多音字取最常用的读音
+//
+// 拼音形式中汉字转为不带声调的拼音，音节之间不加空格，其他字符保持不变，如 "三体 II" 为 "santi ii" 和 "st ii"
+func Key(s string) string {
+	folded := Fold(s)
+
+	var full, initials strings.Builder
+	hasHan := false
+	for _, r := range folded {
+		if unicode.Is(unicode.Han, r) {
+			if readings := pinyin.SinglePinyin(r, pinyinArgs); len(readings) > 0 && readings[0] != "" {
+				syllable := Fold(readings[0])
+				full.WriteString(syllable)
+				initials.WriteString(syllable[:1])
+				hasHan = true
+				continue
+			}
+		}
+		full.WriteRune(r)
+		initials.WriteRune(r)
+	}
+
+	if !hasHan {
+		return folded
+	}
+	return strings.Join([]string{folded, full.String(), initials.String()}, keySeparator)
+}
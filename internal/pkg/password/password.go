@@ -0,0 +1,49 @@
+package password
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hash 使用 bcrypt 生成密码哈希
+func Hash(plain string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsHashed 判断存储的密码是否已是 bcrypt 哈希，旧版本以明文存储密码
+func IsHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// Verify 校验密码，兼容旧版本的明文密码
+func Verify(stored, plain string) (bool, error) {
+	if !IsHashed(stored) {
+		return stored == plain, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(plain))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("collectify"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Burn 消耗与校验一次密码相当的时间，用户不存在时调用，避免通过响应时间推断用户名是否存在
+func Burn(plain string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(plain))
+}
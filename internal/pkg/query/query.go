@@ -0,0 +1,229 @@
+// Package query 解析收藏品搜索的查询语句，如 tag:scifi AND rating>=8 AND NOT status:abandoned AND author:"Le Guin"
+//
+// 语法：
+//
+//	expr    = or
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }    相邻的条件默认为 AND
+//	unary   = ( "NOT" | "-" ) unary | primary
+//	primary = "(" expr ")" | term
+//	term    = key op value | value         只有值时按名称搜索
+//	op      = ":" | "=" | "!=" | "<" | "<=" | ">" | ">="
+//
+// 关键字 AND、OR、NOT 须大写；键和值包含空格或特殊字符时用双引号括起，引号内可用 \" 和 \\ 转义
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 条件的运算符
+const (
+	OpMatch = ":"
+	OpEq    = "="
+	OpNe    = "!="
+	OpLt    = "<"
+	OpLte   = "<="
+	OpGt    = ">"
+	OpGte   = ">="
+)
+
+// Node 查询语法树的节点
+type Node interface {
+	String() string
+}
+
+// And 所有子条件都满足
+type And struct {
+	Nodes []Node
+}
+
+// Or 任一子条件满足
+type Or struct {
+	Nodes []Node
+}
+
+// Not 子条件不满足
+type Not struct {
+	Node Node
+}
+
+// Term 单个条件，Key 为空时表示按名称搜索 Value
+type Term struct {
+	Key   string
+	Op    string
+	Value string
+	Pos   int // 条件在查询语句中的位置，从 1 开始按字符计数
+}
+
+func (n And) String() string { return joinNodes("AND", n.Nodes) }
+func (n Or) String() string  { return joinNodes("OR", n.Nodes) }
+func (n Not) String() string { return "NOT " + n.Node.String() }
+
+func (t Term) String() string {
+	if t.Key == "" {
+		return quote(t.Value)
+	}
+	return quote(t.Key) + t.Op + quote(t.Value)
+}
+
+// Errorf 返回带有条件位置的错误
+func (t Term) Errorf(format string, args ...interface{}) error {
+	return &Error{Pos: t.Pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Error 查询语句的错误，Pos 从 1 开始按字符计数
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Parse 解析查询语句
+func Parse(s string) (Node, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 1, Msg: "empty query"}
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Node, error) {
+	var nodes []Node
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if !p.peek().isKeyword("OR") {
+			break
+		}
+		p.next()
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes []Node
+	for {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		tok := p.peek()
+		if tok.isKeyword("AND") {
+			p.next()
+			continue
+		}
+		// 相邻的条件之间省略了 AND
+		if tok.kind == tokEOF || tok.kind == tokRParen || tok.isKeyword("OR") {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if tok := p.peek(); tok.kind == tokMinus || tok.isKeyword("NOT") {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, &Error{Pos: tok.pos, Msg: "missing closing parenthesis"}
+		}
+		p.next()
+		return node, nil
+
+	case tok.kind == tokEOF:
+		return nil, &Error{Pos: tok.pos, Msg: "unexpected end of query"}
+
+	case tok.kind == tokWord && (tok.isKeyword("AND") || tok.isKeyword("OR")):
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+
+	case tok.kind == tokWord || tok.kind == tokString:
+		if p.peek().kind != tokOp {
+			return Term{Value: tok.text, Pos: tok.pos}, nil
+		}
+		op := p.next()
+		value := p.next()
+		if value.kind != tokWord && value.kind != tokString {
+			return nil, &Error{Pos: value.pos, Msg: fmt.Sprintf("expected value after %q", op.text)}
+		}
+		return Term{Key: tok.text, Op: op.text, Value: value.text, Pos: tok.pos}, nil
+	}
+	return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+}
+
+func joinNodes(op string, nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return "(" + strings.Join(parts, " "+op+" ") + ")"
+}
+
+// quote 在需要时为键或值加上引号，使 String 的结果可以重新解析
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, ` ()":=!<>\`) || strings.HasPrefix(s, "-") || isKeyword(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+// Package timerange 解析搜索中的时间范围，支持 ISO 8601 时间、开放的起止时间和相对时间表达式
+package timerange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range 时间范围，包含起止时间，为空表示不限制
+type Range struct {
+	Start *time.Time
+	End   *time.Time
+}
+
+// 支持的绝对时间格式，只有日期时表示一整天
+var (
+	dateTimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+	}
+	dateLayout = "2006-01-02"
+)
+
+var (
+	lastPattern = regexp.MustCompile(`^last\s+(?:(\d+)\s+)?(day|week|month|year)s?$`)
+	thisPattern = regexp.MustCompile(`^this\s+(week|month|year)$`)
+	agoPattern  = regexp.MustCompile(`^(\d+)\s+(day|week|month|year)s?\s+ago$`)
+)
+
+// Parse 解析时间范围，value 可以是：
+//   - 相对范围：today、yesterday、this week/month/year、last 30 days、last week 等
+//   - 单个时间：日期表示当天，日期时间表示该时刻
+//   - {"start": ..., "end": ...}：起止时间为时间点，可省略其一；只有日期的结束时间包含当天
+//
+// 时间点除 ISO 8601 外还支持 now、today、yesterday 和 30 days ago 等相对表达式，相对时间以 now 为基准
+func Parse(value interface{}, now time.Time) (Range, error) {
+	var r Range
+
+	switch v := value.(type) {
+	case time.Time:
+		r = Range{Start: &v, End: &v}
+	case string:
+		if rr, ok := parseRelativeRange(v, now); ok {
+			return rr, nil
+		}
+		t, wholeDay, err := parsePoint(v, now)
+		if err != nil {
+			return Range{}, err
+		}
+		end := t
+		if wholeDay {
+			end = endOfDay(t)
+		}
+		r = Range{Start: &t, End: &end}
+	case map[string]interface{}:
+		for _, bound := range []struct {
+			key   string
+			isEnd bool
+			dest  **time.Time
+		}{{"start", false, &r.Start}, {"end", true, &r.End}} {
+			s, ok := v[bound.key]
+			if !ok || s == nil || s == "" {
+				continue
+			}
+			str, ok := s.(string)
+			if !ok {
+				return Range{}, fmt.Errorf("invalid %s time: %v", bound.key, s)
+			}
+			t, wholeDay, err := parsePoint(str, now)
+			if err != nil {
+				return Range{}, err
+			}
+			if bound.isEnd && wholeDay {
+				t = endOfDay(t)
+			}
+			*bound.dest = &t
+		}
+	default:
+		return Range{}, fmt.Errorf("invalid time range: %v", value)
+	}
+
+	if r.Start == nil && r.End == nil {
+		return Range{}, fmt.Errorf("empty time range")
+	}
+	if r.Start != nil && r.End != nil && r.Start.After(*r.End) {
+		return Range{}, fmt.Errorf("time range starts after it ends")
+	}
+	return r, nil
+}
+
+// ParsePoint 解析时间点，只有日期时为当天零点
+func ParsePoint(value interface{}, now time.Time) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, _, err := parsePoint(v, now)
+		return t, err
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %v", value)
+}
+
+// parsePoint 解析时间点，wholeDay 表示只精确到天
+func parsePoint(s string, now time.Time) (t time.Time, wholeDay bool, err error) {
+	expr := normalize(s)
+	switch expr {
+	case "now":
+		return now, false, nil
+	case "today":
+		return startOfDay(now), true, nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), true, nil
+	}
+	if m := agoPattern.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid time: %s", s)
+		}
+		return shift(now, m[2], -n), false, nil
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), now.Location()); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.ParseInLocation(dateLayout, strings.TrimSpace(s), now.Location()); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time: %s", s)
+}
+
+// parseRelativeRange 解析相对时间范围
+func parseRelativeRange(s string, now time.Time) (Range, bool) {
+	expr := normalize(s)
+	today := startOfDay(now)
+
+	var start, end time.Time
+	switch {
+	case expr == "today":
+		start, end = today, endOfDay(today)
+	case expr == "yesterday":
+		start = today.AddDate(0, 0, -1)
+		end = endOfDay(start)
+	case thisPattern.MatchString(expr):
+		switch thisPattern.FindStringSubmatch(expr)[1] {
+		case "week":
+			// 以周一为一周的开始
+			start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		case "month":
+			start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		case "year":
+			start = time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location())
+		}
+		end = now
+	case lastPattern.MatchString(expr):
+		m := lastPattern.FindStringSubmatch(expr)
+		n := 1
+		if m[1] != "" {
+			var err error
+			if n, err = strconv.Atoi(m[1]); err != nil {
+				return Range{}, false
+			}
+		}
+		start, end = shift(now, m[2], -n), now
+	default:
+		return Range{}, false
+	}
+	return Range{Start: &start, End: &end}, true
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func shift(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case "day":
+		return t.AddDate(0, 0, n)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(n, 0, 0)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func endOfDay(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
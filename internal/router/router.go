@@ -1,8 +1,11 @@
 package router
 
 import (
+	"collectify/internal/config"
 	"collectify/internal/handler"
 	"collectify/internal/middleware"
+	model "collectify/internal/model/db"
+	"log"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -11,6 +14,11 @@ import (
 func InitRouter() *gin.Engine {
 	r := gin.Default()
 
+	// 仅信任配置的反向代理转发的客户端 IP，避免伪造 IP 绕过登录限制
+	if err := r.SetTrustedProxies(config.GetConfig().Server.TrustedProxies); err != nil {
+		log.Printf("⚠️ 反向代理配置无效：%v\n", err)
+	}
+
 	// 配置 CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:3000"}
@@ -23,6 +31,7 @@ func InitRouter() *gin.Engine {
 	{
 		initCategoryRouter(api)
 		initCollectionRouter(api)
+		initDeletedRouter(api)
 		initFieldRouter(api)
 		initItemRouter(api)
 		initTagRouter(api)
@@ -38,10 +47,11 @@ func InitRouter() *gin.Engine {
 func initCategoryRouter(router *gin.RouterGroup) {
 	category := router.Group("/category")
 	{
+		category.Use(middleware.OptionalAuthCheck, middleware.PermissionCheck(model.PermissionRead))
 		category.GET("/:id", handler.GetCategory)
 		category.GET("/list", handler.ListCategory)
 
-		category.Use(middleware.AuthCheck)
+		category.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionWrite))
 		category.POST("", handler.CreateCategory)
 		category.PATCH("/:id", handler.RenameCategory)
 		category.DELETE("/:id", handler.DeleteCategory)
@@ -52,10 +62,11 @@ func initCategoryRouter(router *gin.RouterGroup) {
 func initCollectionRouter(router *gin.RouterGroup) {
 	collection := router.Group("/collection")
 	{
+		collection.Use(middleware.OptionalAuthCheck, middleware.PermissionCheck(model.PermissionRead))
 		collection.GET("/:id", handler.GetCollection)
 		collection.GET("/list", handler.ListCollection)
 
-		collection.Use(middleware.AuthCheck)
+		collection.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionWrite))
 		collection.POST("", handler.CreateCollection)
 		collection.PATCH("/:id", handler.UpdateCollection)
 		collection.DELETE("/:id", handler.DeleteCollection)
@@ -63,11 +74,22 @@ func initCollectionRouter(router *gin.RouterGroup) {
 	}
 }
 
+func initDeletedRouter(router *gin.RouterGroup) {
+	deleted := router.Group("/deleted")
+	{
+		deleted.Use(middleware.AuthCheck)
+		deleted.GET("", middleware.PermissionCheck(model.PermissionRead), handler.ListDeleted)
+		deleted.POST("/restore", middleware.PermissionCheck(model.PermissionWrite), handler.RestoreDeleted)
+		deleted.POST("/purge", middleware.PermissionCheck(model.PermissionPurge), handler.PurgeDeleted)
+	}
+}
+
 func initFieldRouter(router *gin.RouterGroup) {
 	field := router.Group("/field")
 	{
-		field.Use(middleware.AuthCheck)
+		field.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionWrite))
 		field.POST("", handler.CreateField)
+		field.PUT("/:id/options", handler.UpdateFieldOptions)
 		field.DELETE("/:id", handler.DeleteField)
 		field.POST("/:id/restore", handler.RestoreField)
 	}
@@ -76,11 +98,12 @@ func initFieldRouter(router *gin.RouterGroup) {
 func initItemRouter(router *gin.RouterGroup) {
 	item := router.Group("/item")
 	{
+		item.Use(middleware.OptionalAuthCheck, middleware.PermissionCheck(model.PermissionRead))
 		item.GET("/list", handler.ListItems)
 		item.POST("/search", handler.SearchItems)
 		item.GET("/:id", handler.GetItem)
 
-		item.Use(middleware.AuthCheck)
+		item.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionWrite))
 		item.POST("", handler.CreateItem)
 		item.DELETE("/:id", handler.DeleteItem)
 		item.PUT("/:id", handler.UpdateItem)
@@ -97,10 +120,11 @@ func initItemRouter(router *gin.RouterGroup) {
 func initTagRouter(router *gin.RouterGroup) {
 	tag := router.Group("/tag")
 	{
+		tag.Use(middleware.OptionalAuthCheck, middleware.PermissionCheck(model.PermissionRead))
 		tag.GET("/:id", handler.GetTag)
 		tag.GET("/list", handler.ListTag)
 
-		tag.Use(middleware.AuthCheck)
+		tag.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionWrite))
 		tag.POST("", handler.CreateTag)
 		tag.PATCH("/:id", handler.RenameTag)
 		tag.DELETE("/:id", handler.DeleteTag)
@@ -113,8 +137,35 @@ func initUserRouter(router *gin.RouterGroup) {
 	{
 		user.POST("/login", handler.UserLogin)
 		user.GET("/enabled", handler.IsAuthEnabled)
-
-		user.Use(middleware.AuthCheck)
-		user.POST("/update", handler.UserUpdate)
+		user.POST("/login/totp", handler.UserLoginTOTP)
+		user.GET("/oidc/enabled", handler.IsOIDCEnabled)
+		user.GET("/oidc/login", handler.OIDCLogin)
+		user.POST("/oidc/callback", handler.OIDCCallback)
+		user.POST("/token", handler.UserTokenPair)
+		user.POST("/token/refresh", handler.UserRefreshToken)
+		user.POST("/logout", middleware.PasswordChangeAuthCheck, middleware.SessionOnlyCheck, handler.UserLogout)
+		user.POST("/update", middleware.PasswordChangeAuthCheck, middleware.SessionOnlyCheck, handler.UserUpdate)
+
+		// 两步验证
+		totp := user.Group("/totp", middleware.AuthCheck, middleware.SessionOnlyCheck)
+		totp.POST("/setup", handler.SetupTOTP)
+		totp.POST("/enable", handler.EnableTOTP)
+		totp.POST("/disable", handler.DisableTOTP)
+
+		// 个人访问令牌
+		tokens := user.Group("/tokens", middleware.AuthCheck, middleware.SessionOnlyCheck)
+		tokens.GET("", handler.ListAPITokens)
+		tokens.POST("", handler.CreateAPIToken)
+		tokens.DELETE("/:id", handler.DeleteAPIToken)
+
+		// 用户管理
+		user.Use(middleware.AuthCheck, middleware.PermissionCheck(model.PermissionManageUser))
+		user.GET("/list", handler.ListUsers)
+		user.GET("/audit", handler.ListLoginAudits)
+		user.POST("", handler.CreateUser)
+		user.POST("/:id/role", handler.UpdateUserRole)
+		user.POST("/:id/totp/reset", handler.ResetUserTOTP)
+		user.POST("/:id/disable", handler.DisableUser)
+		user.POST("/:id/enable", handler.EnableUser)
 	}
 }
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	"collectify/internal/model/common"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	loginThrottleWindow = 24 * time.Hour // 统计连续登录失败次数的时间范围
+	maxLoginLockout     = time.Hour      // 锁定时长上限
+)
+
+// LoginClient 发起登录的客户端信息
+type LoginClient struct {
+	IP        string
+	UserAgent string
+}
+
+// 记录登录审计日志
+func recordLogin(userID uint, username string, client LoginClient, result string, reason error) error {
+	audit := model.LoginAudit{
+		UserID:    userID,
+		Username:  username,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Result:    result,
+	}
+	if reason != nil {
+		audit.Reason = reason.Error()
+	}
+	return dao.Create(conn.GetDB(), &audit)
+}
+
+// 检查用户名和 IP 是否被锁定，被锁定时记录审计日志并返回错误
+func checkLoginLocked(userID uint, username string, client LoginClient) error {
+	retryAfter, err := checkLoginThrottle(username, client.IP)
+	if err != nil {
+		return err
+	}
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	err = e.ErrTooManyAttempts.Wrap(fmt.Errorf("retry after %d seconds", int(math.Ceil(retryAfter.Seconds()))))
+	if recordErr := recordLogin(userID, username, client, model.LoginResultLocked, err); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+// 检查用户名和 IP 是否因连续登录失败被锁定，返回剩余锁定时长
+// 用户名成功登录后重新计算该用户名的失败次数；IP 的失败次数只排除同一用户名在该 IP 上成功登录之前的失败，
+// 避免攻击者用自己的账号登录来清空猜测其他用户名的失败次数
+func checkLoginThrottle(username, ip string) (time.Duration, error) {
+	cfg := config.GetConfig().Auth
+	db := conn.GetDB()
+	now := time.Now()
+
+	byUsername, err := loginLockedUntil(db, dao.Filter{Where: "username = ?", Args: []interface{}{username}},
+		[]string{"username"}, cfg.LoginMaxAttempts, cfg.LoginLockout, now)
+	if err != nil {
+		return 0, err
+	}
+	byIP, err := loginLockedUntil(db, dao.Filter{Where: "ip = ?", Args: []interface{}{ip}},
+		[]string{"username", "ip"}, cfg.LoginMaxAttemptsPerIP, cfg.LoginLockout, now)
+	if err != nil {
+		return 0, err
+	}
+
+	lockedUntil := byUsername
+	if byIP.After(lockedUntil) {
+		lockedUntil = byIP
+	}
+	if lockedUntil.After(now) {
+		return lockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// 根据 filter 匹配的连续失败次数计算锁定截止时间，resetColumns 相同的记录之后成功登录过的失败不计入
+// 达到 maxAttempts 次失败后锁定 lockout，此后每多失败一次锁定时长翻倍，最长 maxLoginLockout
+func loginLockedUntil(db *gorm.DB, filter dao.Filter, resetColumns []string, maxAttempts int, lockout time.Duration, now time.Time) (time.Time, error) {
+	if maxAttempts <= 0 || lockout <= 0 {
+		return time.Time{}, nil
+	}
+
+	reset := "SELECT 1 FROM login_audits s WHERE s.result = ? AND s.created_at > login_audits.created_at"
+	for _, column := range resetColumns {
+		reset += fmt.Sprintf(" AND s.%s = login_audits.%s", column, column)
+	}
+
+	var failures []model.LoginAudit
+	err := db.Where(filter.Where, filter.Args...).
+		Where("result = ? AND created_at > ?", model.LoginResultFailure, now.Add(-loginThrottleWindow)).
+		Where("NOT EXISTS ("+reset+")", model.LoginResultSuccess).
+		Order("created_at DESC").Find(&failures).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(failures) < maxAttempts {
+		return time.Time{}, nil
+	}
+
+	duration := lockout
+	for i := maxAttempts; i < len(failures) && duration < maxLoginLockout; i++ {
+		duration *= 2
+	}
+	if duration > maxLoginLockout {
+		duration = maxLoginLockout
+	}
+	return failures[0].CreatedAt.Add(duration), nil
+}
+
+// ListLoginAudits 查询登录审计日志，按时间逆序排序
+func ListLoginAudits(username, ip, result string, p common.Pagination) ([]model.LoginAudit, int64, error) {
+	filters := []dao.Filter{}
+	if username != "" {
+		filters = append(filters, dao.Filter{
+			Where: "username = ?",
+			Args:  []interface{}{username},
+		})
+	}
+	if ip != "" {
+		filters = append(filters, dao.Filter{
+			Where: "ip = ?",
+			Args:  []interface{}{ip},
+		})
+	}
+	if result != "" {
+		filters = append(filters, dao.Filter{
+			Where: "result = ?",
+			Args:  []interface{}{result},
+		})
+	}
+	orderBy := []dao.OrderBy{
+		{
+			Column: "created_at",
+			Desc:   true,
+		},
+		{
+			Column: "id",
+			Desc:   true,
+		},
+	}
+
+	return dao.GetList[model.LoginAudit](conn.GetDB(), filters, orderBy, p)
+}
@@ -5,20 +5,27 @@ import (
 	"collectify/internal/conn"
 	"collectify/internal/dao"
 	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"fmt"
 
 	"gorm.io/gorm"
 )
 
 // DeleteCategory 删除分类
-func DeleteCategory(categoryID uint) error {
+func DeleteCategory(categoryID uint, userID uint) error {
 	db := conn.GetDB()
 	cfg := config.GetConfig()
 	isSoftDelete := cfg.RecycleBin.Enable
 
 	err := db.Transaction(func(tx *gorm.DB) error {
+		err := checkOwner[model.Category](tx, categoryID, userID)
+		if err != nil {
+			return err
+		}
+
 		// 获取分类下的字段ID
 		var fieldIDs []uint
-		err := tx.Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
+		err = tx.Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
 		if err != nil {
 			return err
 		}
@@ -37,7 +44,13 @@ func DeleteCategory(categoryID uint) error {
 
 		var uniqueFields map[string]interface{}
 
-		// 删除分类下的字段
+		// 删除分类下的字段，软删除时保留选项以便恢复
+		if !isSoftDelete {
+			err = deleteFieldOptions(tx, fieldIDs)
+			if err != nil {
+				return err
+			}
+		}
 		uniqueFields = map[string]interface{}{"category_id": categoryID}
 		err = dao.Delete[model.Field](tx, uniqueFields, isSoftDelete)
 		if err != nil {
@@ -58,61 +71,86 @@ func DeleteCategory(categoryID uint) error {
 			return err
 		}
 
-		return nil
+		// 彻底删除时清理分类下收藏品的标签、收藏夹关联、别名和全文索引，以及其他收藏品关联它们的字段值
+		if !isSoftDelete {
+			return purgeOrphans(tx)
+		}
+		return dao.IndexItemsByFilter(tx, categoryItemsFilter(categoryID))
 	})
 	return err
 }
 
 // RestoreCategory 恢复分类
-func RestoreCategory(categoryID uint) error {
+func RestoreCategory(categoryID uint, userID uint) error {
 	db := conn.GetDB()
 
 	err := db.Transaction(func(tx *gorm.DB) error {
-		// 获取分类下的字段ID
-		var fieldIDs []uint
-		err := tx.Unscoped().Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
-		if err != nil {
+		if err := checkOwner[model.Category](tx, categoryID, userID); err != nil {
 			return err
 		}
+		return restoreCategory(tx, categoryID)
+	})
+	return err
+}
 
-		var uniqueFields map[string]interface{}
+// restoreCategory 恢复分类及分类下的字段、收藏品和字段值
+func restoreCategory(tx *gorm.DB, categoryID uint) error {
+	err := checkCategoryConflict(tx, categoryID)
+	if err != nil {
+		return err
+	}
 
-		// 恢复分类
-		uniqueFields = map[string]interface{}{"id": categoryID}
-		err = dao.Restore[model.Category](tx, uniqueFields)
-		if err != nil {
-			return err
-		}
+	// 获取分类下的字段ID
+	var fieldIDs []uint
+	err = tx.Unscoped().Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
+	if err != nil {
+		return err
+	}
 
-		// 恢复分类下的字段
-		uniqueFields = map[string]interface{}{"category_id": categoryID}
-		err = dao.Restore[model.Field](tx, uniqueFields)
-		if err != nil {
-			return err
-		}
+	var uniqueFields map[string]interface{}
 
-		// 恢复分类下的收藏品
-		uniqueFields = map[string]interface{}{"category_id": categoryID}
-		err = dao.Restore[model.Item](tx, uniqueFields)
-		if err != nil {
-			return err
-		}
+	// 恢复分类
+	uniqueFields = map[string]interface{}{"id": categoryID}
+	err = dao.Restore[model.Category](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
 
-		// 恢复分类下的字段值
-		filters := []dao.Filter{
-			{
-				Where: "field_id IN (?)",
-				Args:  []interface{}{fieldIDs},
-			},
-		}
-		err = dao.RestoreByFilter[model.ItemFieldValue](tx, filters)
-		if err != nil {
-			return err
-		}
+	// 恢复分类下的字段
+	uniqueFields = map[string]interface{}{"category_id": categoryID}
+	err = dao.Restore[model.Field](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
-	return err
+	// 恢复分类下的收藏品
+	uniqueFields = map[string]interface{}{"category_id": categoryID}
+	err = dao.Restore[model.Item](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
+
+	// 恢复分类下的字段值
+	filters := []dao.Filter{
+		{
+			Where: "field_id IN (?)",
+			Args:  []interface{}{fieldIDs},
+		},
+	}
+	err = dao.RestoreByFilter[model.ItemFieldValue](tx, filters)
+	if err != nil {
+		return err
+	}
+
+	return dao.IndexItemsByFilter(tx, categoryItemsFilter(categoryID))
+}
+
+// categoryItemsFilter 分类下的收藏品，用于更新全文索引
+func categoryItemsFilter(categoryID uint) dao.Filter {
+	return dao.Filter{
+		Where: "items.category_id = ?",
+		Args:  []interface{}{categoryID},
+	}
 }
 
 // tryRestoreCategory 尝试仅恢复分类
@@ -129,6 +167,11 @@ func tryRestoreCategory(tx *gorm.DB, categoryID uint) error {
 		return nil
 	}
 
+	err = checkCategoryConflict(tx, categoryID)
+	if err != nil {
+		return err
+	}
+
 	// 恢复分类
 	uniqueFields := map[string]interface{}{"id": categoryID}
 	err = dao.Restore[model.Category](tx, uniqueFields)
@@ -158,6 +201,11 @@ func tryRestoreCategoryWithFields(tx *gorm.DB, categoryID uint) error {
 		return nil
 	}
 
+	err = checkCategoryConflict(tx, categoryID)
+	if err != nil {
+		return err
+	}
+
 	// 获取分类下的字段ID
 	var fieldIDs []uint
 	err = tx.Unscoped().Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
@@ -183,3 +231,31 @@ func tryRestoreCategoryWithFields(tx *gorm.DB, categoryID uint) error {
 
 	return nil
 }
+
+// checkCategoryConflict 检查是否存在同名的未删除分类，存在时无法恢复
+func checkCategoryConflict(tx *gorm.DB, categoryID uint) error {
+	var category model.Category
+	err := tx.Unscoped().Model(&category).Where("id = ?", categoryID).First(&category).Error
+	if err != nil {
+		return err
+	}
+
+	uniqueFields := map[string]interface{}{
+		"user_id": category.UserID,
+		"name":    category.Name,
+	}
+	filters := []dao.Filter{
+		{
+			Where: "id != ? AND deleted_at IS NULL",
+			Args:  []interface{}{categoryID},
+		},
+	}
+	id, _, err := dao.DuplicateCheck[model.Category](tx, uniqueFields, filters)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		return e.ErrDuplicated.Wrap(fmt.Errorf("category %s", category.Name))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	model "collectify/internal/model/db"
+
+	"gorm.io/gorm"
+)
+
+// RestoreCollection 恢复收藏夹
+func RestoreCollection(collectionID uint, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOwner[model.Collection](tx, collectionID, userID); err != nil {
+			return err
+		}
+		return restoreCollection(tx, collectionID)
+	})
+
+	return err
+}
+
+// restoreCollection 恢复收藏夹
+func restoreCollection(tx *gorm.DB, collectionID uint) error {
+	uniqueFields := map[string]interface{}{"id": collectionID}
+	return dao.Restore[model.Collection](tx, uniqueFields)
+}
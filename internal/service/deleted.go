@@ -4,6 +4,14 @@ import (
 	"collectify/internal/conn"
 	"collectify/internal/dao"
 	model "collectify/internal/model/db"
+	define "collectify/internal/model/define"
+	"collectify/internal/pkg/e"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,19 +25,494 @@ var DeleteByFilterFuncs = map[string]func(tx *gorm.DB, filters []dao.Filter, isS
 	model.ModelTypeIFV:        dao.DeleteByFilter[model.ItemFieldValue],
 }
 
-func ClearRecycleBin() error {
-	db := conn.GetDB()
+// 回收站支持的类型，检查记录是否处于删除状态
+var isDeletedFuncs = map[string]func(tx *gorm.DB, uniqueFields map[string]interface{}) (bool, error){
+	model.ModelTypeCategory:   dao.IsDeleted[model.Category],
+	model.ModelTypeCollection: dao.IsDeleted[model.Collection],
+	model.ModelTypeField:      dao.IsDeleted[model.Field],
+	model.ModelTypeItem:       dao.IsDeleted[model.Item],
+	model.ModelTypeTag:        dao.IsDeleted[model.Tag],
+}
+
+// 回收站支持的类型，恢复记录
+var restoreFuncs = map[string]func(tx *gorm.DB, id uint) error{
+	model.ModelTypeCategory:   restoreCategory,
+	model.ModelTypeCollection: restoreCollection,
+	model.ModelTypeField:      restoreField,
+	model.ModelTypeItem:       restoreItem,
+	model.ModelTypeTag:        restoreTag,
+}
+
+// 回收站支持的类型，彻底删除记录
+var purgeFuncs = map[string]func(tx *gorm.DB, id uint) error{
+	model.ModelTypeCategory:   purgeCategory,
+	model.ModelTypeCollection: purgeCollection,
+	model.ModelTypeField:      purgeField,
+	model.ModelTypeItem:       purgeItem,
+	model.ModelTypeTag:        purgeTag,
+}
 
+// PurgeExpired 彻底删除 before 之前删除的记录
+func PurgeExpired(before time.Time) error {
 	filters := []dao.Filter{
 		{
-			Where: "deleted_at is not null",
+			Where: "deleted_at is not null AND deleted_at < ?",
+			Args:  []interface{}{before},
 		},
 	}
-	for _, fn := range DeleteByFilterFuncs {
-		err := fn(db, filters, false)
+	return purgeByFilter(filters)
+}
+
+// RunRecycleBinJanitor 定期清理超过保留天数的删除记录，直到 ctx 取消
+func RunRecycleBinJanitor(ctx context.Context, retention time.Duration, interval time.Duration) {
+	if retention <= 0 || interval <= 0 {
+		return
+	}
+
+	purge := func() {
+		if err := PurgeExpired(time.Now().Add(-retention)); err != nil {
+			log.Printf("❌ 回收站自动清理失败：%v\n", err)
+		}
+	}
+
+	purge()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purge()
+		}
+	}
+}
+
+// purgeByFilter 按条件彻底删除所有类型的记录，并清理失去关联的记录
+func purgeByFilter(filters []dao.Filter) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, fn := range DeleteByFilterFuncs {
+			err := fn(tx, filters, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		return purgeOrphans(tx)
+	})
+
+	return err
+}
+
+// purgeOrphans 清理所属或关联记录已被彻底删除的关联记录、选项、字段值、别名和全文索引
+func purgeOrphans(tx *gorm.DB) error {
+	// 关联的收藏品、标签或收藏夹已被彻底删除的关联记录
+	orphanFilters := map[string][]dao.Filter{
+		model.JoinTableItemTags: {
+			{
+				Where: "item_id NOT IN (SELECT id FROM items) OR tag_id NOT IN (SELECT id FROM tags)",
+			},
+		},
+		model.JoinTableCollectionItems: {
+			{
+				Where: "item_id NOT IN (SELECT id FROM items) OR collection_id NOT IN (SELECT id FROM collections)",
+			},
+		},
+	}
+	for table, filters := range orphanFilters {
+		err := dao.DeleteJoinRows(tx, table, filters)
 		if err != nil {
 			return err
 		}
 	}
+
+	// 所属字段已被彻底删除的选项
+	optionFilters := []dao.Filter{
+		{
+			Where: "field_id NOT IN (SELECT id FROM fields)",
+		},
+	}
+	err := dao.DeleteByFilter[model.FieldOption](tx, optionFilters, false)
+	if err != nil {
+		return err
+	}
+
+	// 关联的收藏品已被彻底删除的关联字段值
+	referenceFilters := []dao.Filter{
+		{
+			Where: "value_item IS NOT NULL AND value_item NOT IN (SELECT id FROM items)",
+		},
+	}
+	err = dao.DeleteByFilter[model.ItemFieldValue](tx, referenceFilters, false)
+	if err != nil {
+		return err
+	}
+
+	// 所属收藏品已被彻底删除的别名
+	err = dao.DeleteByFilter[model.ItemAlias](tx, []dao.Filter{orphanAliasesFilter}, false)
+	if err != nil {
+		return err
+	}
+
+	// 已被彻底删除的收藏品的全文索引
+	return dao.PruneItemIndex(tx)
+}
+
+// ListDeleted 列出用户回收站中的记录，modelType 为空时列出所有类型
+func ListDeleted(modelType string, userID uint) ([]define.DeletedRecord, error) {
+	db := conn.GetDB()
+	filters := dao.OwnerFilter("", userID)
+
+	categories, err := dao.GetDeletedList[model.Category](db, filters)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := dao.GetDeletedList[model.Field](db, filters)
+	if err != nil {
+		return nil, err
+	}
+	items, err := dao.GetDeletedList[model.Item](db, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已删除分类，以及分类下已删除的字段和收藏品
+	categoryMap := make(map[uint]model.Category)
+	for _, category := range categories {
+		categoryMap[category.ID] = category
+	}
+	categoryFields := make(map[uint][]define.DeletedRelated)
+	for _, field := range fields {
+		categoryFields[field.CategoryID] = append(categoryFields[field.CategoryID], define.DeletedRelated{
+			ID:   field.ID,
+			Type: model.ModelTypeField,
+			Name: field.Name,
+		})
+	}
+	categoryItems := make(map[uint][]define.DeletedRelated)
+	for _, item := range items {
+		categoryItems[item.CategoryID] = append(categoryItems[item.CategoryID], define.DeletedRelated{
+			ID:   item.ID,
+			Type: model.ModelTypeItem,
+			Name: item.Name,
+		})
+	}
+
+	// 所属分类已删除时，恢复字段或收藏品会一并恢复分类
+	deletedCategory := func(categoryID uint) []define.DeletedRelated {
+		category, ok := categoryMap[categoryID]
+		if !ok {
+			return nil
+		}
+		return []define.DeletedRelated{{
+			ID:   category.ID,
+			Type: model.ModelTypeCategory,
+			Name: category.Name,
+		}}
+	}
+
+	var records []define.DeletedRecord
+
+	if modelType == "" || modelType == model.ModelTypeCategory {
+		for _, category := range categories {
+			restores := append([]define.DeletedRelated{}, categoryFields[category.ID]...)
+			restores = append(restores, categoryItems[category.ID]...)
+			records = append(records, define.DeletedRecord{
+				ID:        category.ID,
+				Type:      model.ModelTypeCategory,
+				Name:      category.Name,
+				DeletedAt: category.DeletedAt.Time,
+				Restores:  restores,
+			})
+		}
+	}
+
+	if modelType == "" || modelType == model.ModelTypeField {
+		for _, field := range fields {
+			records = append(records, define.DeletedRecord{
+				ID:        field.ID,
+				Type:      model.ModelTypeField,
+				Name:      field.Name,
+				DeletedAt: field.DeletedAt.Time,
+				Restores:  deletedCategory(field.CategoryID),
+			})
+		}
+	}
+
+	if modelType == "" || modelType == model.ModelTypeItem {
+		for _, item := range items {
+			// 分类已删除时会连同分类下的字段一起恢复
+			restores := deletedCategory(item.CategoryID)
+			if len(restores) > 0 {
+				restores = append(restores, categoryFields[item.CategoryID]...)
+			}
+			records = append(records, define.DeletedRecord{
+				ID:        item.ID,
+				Type:      model.ModelTypeItem,
+				Name:      item.Name,
+				DeletedAt: item.DeletedAt.Time,
+				Restores:  restores,
+			})
+		}
+	}
+
+	if modelType == "" || modelType == model.ModelTypeTag {
+		tags, err := dao.GetDeletedList[model.Tag](db, filters)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			records = append(records, define.DeletedRecord{
+				ID:        tag.ID,
+				Type:      model.ModelTypeTag,
+				Name:      tag.Name,
+				DeletedAt: tag.DeletedAt.Time,
+			})
+		}
+	}
+
+	if modelType == "" || modelType == model.ModelTypeCollection {
+		collections, err := dao.GetDeletedList[model.Collection](db, filters)
+		if err != nil {
+			return nil, err
+		}
+		for _, collection := range collections {
+			records = append(records, define.DeletedRecord{
+				ID:        collection.ID,
+				Type:      model.ModelTypeCollection,
+				Name:      collection.Name,
+				DeletedAt: collection.DeletedAt.Time,
+			})
+		}
+	}
+
+	// 删除时间逆序排序
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DeletedAt.After(records[j].DeletedAt)
+	})
+
+	return records, nil
+}
+
+// RestoreDeleted 批量恢复回收站中的记录
+func RestoreDeleted(list []define.DeletedReqItem, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range list {
+			if err := checkDeleted(tx, target, userID); err != nil {
+				return err
+			}
+			if err := restoreFuncs[target.Type](tx, target.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return err
+}
+
+// PurgeDeleted 批量彻底删除回收站中的记录
+func PurgeDeleted(list []define.DeletedReqItem, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range list {
+			if err := checkDeleted(tx, target, userID); err != nil {
+				return err
+			}
+			if err := purgeFuncs[target.Type](tx, target.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return err
+}
+
+// checkDeleted 检查目标是否存在、属于用户且处于回收站中
+func checkDeleted(tx *gorm.DB, target define.DeletedReqItem, userID uint) error {
+	isDeletedFunc, ok := isDeletedFuncs[target.Type]
+	if !ok {
+		return e.ErrInvalidParams.Wrap(fmt.Errorf("unsupported type: %s", target.Type))
+	}
+
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": target.ID}, userID)
+	isDeleted, err := isDeletedFunc(tx, uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrNotFound.Wrap(fmt.Errorf("%s %d", target.Type, target.ID))
+		}
+		return err
+	}
+	if !isDeleted {
+		return e.ErrNotDeleted.Wrap(fmt.Errorf("%s %d", target.Type, target.ID))
+	}
 	return nil
 }
+
+// purgeCategory 彻底删除分类及分类下的字段、收藏品和字段值
+func purgeCategory(tx *gorm.DB, categoryID uint) error {
+	var fieldIDs []uint
+	err := tx.Unscoped().Model(&model.Field{}).Where("category_id = ?", categoryID).Pluck("id", &fieldIDs).Error
+	if err != nil {
+		return err
+	}
+
+	var itemIDs []uint
+	err = tx.Unscoped().Model(&model.Item{}).Where("category_id = ?", categoryID).Pluck("id", &itemIDs).Error
+	if err != nil {
+		return err
+	}
+
+	// 彻底删除分类下的收藏品
+	err = purgeItems(tx, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	// 彻底删除分类下的字段值和字段
+	filters := []dao.Filter{
+		{
+			Where: "field_id IN (?)",
+			Args:  []interface{}{fieldIDs},
+		},
+	}
+	err = dao.DeleteByFilter[model.ItemFieldValue](tx, filters, false)
+	if err != nil {
+		return err
+	}
+
+	err = deleteFieldOptions(tx, fieldIDs)
+	if err != nil {
+		return err
+	}
+
+	uniqueFields := map[string]interface{}{"category_id": categoryID}
+	err = dao.Delete[model.Field](tx, uniqueFields, false)
+	if err != nil {
+		return err
+	}
+
+	// 彻底删除分类
+	uniqueFields = map[string]interface{}{"id": categoryID}
+	return dao.Delete[model.Category](tx, uniqueFields, false)
+}
+
+// purgeField 彻底删除字段及其字段值和选项
+func purgeField(tx *gorm.DB, fieldID uint) error {
+	uniqueFields := map[string]interface{}{"field_id": fieldID}
+	err := dao.Delete[model.ItemFieldValue](tx, uniqueFields, false)
+	if err != nil {
+		return err
+	}
+
+	err = deleteFieldOptions(tx, []uint{fieldID})
+	if err != nil {
+		return err
+	}
+
+	uniqueFields = map[string]interface{}{"id": fieldID}
+	return dao.Delete[model.Field](tx, uniqueFields, false)
+}
+
+// purgeItem 彻底删除收藏品
+func purgeItem(tx *gorm.DB, itemID uint) error {
+	return purgeItems(tx, []uint{itemID})
+}
+
+// purgeItems 彻底删除收藏品及其字段值、标签关联和收藏夹关联
+func purgeItems(tx *gorm.DB, itemIDs []uint) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	filters := []dao.Filter{
+		{
+			Where: "item_id IN (?)",
+			Args:  []interface{}{itemIDs},
+		},
+	}
+	err := dao.DeleteByFilter[model.ItemFieldValue](tx, filters, false)
+	if err != nil {
+		return err
+	}
+	err = dao.DeleteByFilter[model.ItemAlias](tx, filters, false)
+	if err != nil {
+		return err
+	}
+	err = deleteItemReferences(tx, itemIDs)
+	if err != nil {
+		return err
+	}
+	err = dao.DeleteJoinRows(tx, model.JoinTableItemTags, filters)
+	if err != nil {
+		return err
+	}
+	err = dao.DeleteJoinRows(tx, model.JoinTableCollectionItems, filters)
+	if err != nil {
+		return err
+	}
+
+	filters = []dao.Filter{
+		{
+			Where: "id IN (?)",
+			Args:  []interface{}{itemIDs},
+		},
+	}
+	err = dao.DeleteByFilter[model.Item](tx, filters, false)
+	if err != nil {
+		return err
+	}
+	return dao.IndexItems(tx, itemIDs)
+}
+
+// deleteItemReferences 彻底删除其他收藏品关联这些收藏品的字段值
+func deleteItemReferences(tx *gorm.DB, itemIDs []uint) error {
+	filters := []dao.Filter{
+		{
+			Where: "value_item IN (?)",
+			Args:  []interface{}{itemIDs},
+		},
+	}
+	return dao.DeleteByFilter[model.ItemFieldValue](tx, filters, false)
+}
+
+// purgeTag 彻底删除标签及其收藏品关联
+func purgeTag(tx *gorm.DB, tagID uint) error {
+	filters := []dao.Filter{
+		{
+			Where: "tag_id = ?",
+			Args:  []interface{}{tagID},
+		},
+	}
+	err := dao.DeleteJoinRows(tx, model.JoinTableItemTags, filters)
+	if err != nil {
+		return err
+	}
+
+	uniqueFields := map[string]interface{}{"id": tagID}
+	return dao.Delete[model.Tag](tx, uniqueFields, false)
+}
+
+// purgeCollection 彻底删除收藏夹及其收藏品关联
+func purgeCollection(tx *gorm.DB, collectionID uint) error {
+	filters := []dao.Filter{
+		{
+			Where: "collection_id = ?",
+			Args:  []interface{}{collectionID},
+		},
+	}
+	err := dao.DeleteJoinRows(tx, model.JoinTableCollectionItems, filters)
+	if err != nil {
+		return err
+	}
+
+	uniqueFields := map[string]interface{}{"id": collectionID}
+	return dao.Delete[model.Collection](tx, uniqueFields, false)
+}
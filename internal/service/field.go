@@ -5,67 +5,260 @@ import (
 	"collectify/internal/conn"
 	"collectify/internal/dao"
 	model "collectify/internal/model/db"
+	define "collectify/internal/model/define"
+	"collectify/internal/pkg/e"
+	"errors"
+	"fmt"
 
 	"gorm.io/gorm"
 )
 
-// DeleteField 删除字段
-func DeleteField(fieldID uint) error {
+// CheckFieldOptions 检查字段的可选项，选择字段至少需要一个选项且选项名称不能重复，其他类型的字段不能设置选项
+func CheckFieldOptions(fieldType int, options []define.FieldOption) error {
+	if fieldType != model.FieldTypeSelect {
+		if len(options) > 0 {
+			return e.ErrInvalidParams.Wrap(errors.New("only select fields have options"))
+		}
+		return nil
+	}
+
+	if len(options) == 0 {
+		return e.ErrInvalidParams.Wrap(errors.New("select field requires at least one option"))
+	}
+	values := make(map[string]bool)
+	for _, option := range options {
+		if values[option.Value] {
+			return e.ErrInvalidParams.Wrap(fmt.Errorf("duplicated option %s", option.Value))
+		}
+		values[option.Value] = true
+	}
+	return nil
+}
+
+// CheckFieldUnit 检查字段的单位和精度，仅小数字段可以设置
+func CheckFieldUnit(fieldType int, unit string, precision *int) error {
+	if fieldType != model.FieldTypeFloat && (unit != "" || precision != nil) {
+		return e.ErrInvalidParams.Wrap(errors.New("only float fields have unit and precision"))
+	}
+	return nil
+}
+
+// CheckFieldTarget 检查关联字段限定的分类，仅关联字段可以设置，分类需存在且与字段属于同一用户
+func CheckFieldTarget(fieldType int, targetCategoryID *uint, userID uint) error {
+	if targetCategoryID == nil {
+		return nil
+	}
+	if fieldType != model.FieldTypeReference {
+		return e.ErrInvalidParams.Wrap(errors.New("only reference fields have a target category"))
+	}
+
+	uniqueFields := dao.OwnedBy(map[string]interface{}{"id": *targetCategoryID}, userID)
+	_, err := dao.Get[model.Category](conn.GetDB(), uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrInvalidParams.Wrap(fmt.Errorf("target category %d not found", *targetCategoryID))
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateFieldOptions 更新选择字段的可选项，按列表顺序排序
+// 带 ID 的选项修改名称和颜色，已有的字段值保持不变；不带 ID 的选项为新增；未列出的选项仅在未被使用时删除
+func UpdateFieldOptions(fieldID uint, userID uint, options []define.FieldOption) error {
 	db := conn.GetDB()
-	cfg := config.GetConfig()
-	isSoftDelete := cfg.RecycleBin.Enable
 
 	err := db.Transaction(func(tx *gorm.DB) error {
-		var uniqueFields map[string]interface{}
-		var err error
-
-		// 删除字段值
-		uniqueFields = map[string]interface{}{"field_id": fieldID}
-		err = dao.Delete[model.ItemFieldValue](tx, uniqueFields, isSoftDelete)
-		if err != nil {
+		if err := checkOwner[model.Field](tx, fieldID, userID); err != nil {
 			return err
 		}
 
-		// 删除字段
-		uniqueFields = map[string]interface{}{"id": fieldID}
-		err = dao.Delete[model.Field](tx, uniqueFields, isSoftDelete)
+		uniqueFields := map[string]interface{}{"id": fieldID}
+		field, err := dao.Get[model.Field](tx, uniqueFields, "Options")
 		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e.ErrNotFound
+			}
+			return err
+		}
+		if field.Type != model.FieldTypeSelect {
+			return e.ErrInvalidParams.Wrap(errors.New("only select fields have options"))
+		}
+		if err := CheckFieldOptions(field.Type, options); err != nil {
 			return err
 		}
 
+		removed := make(map[uint]model.FieldOption)
+		for _, option := range field.Options {
+			removed[option.ID] = option
+		}
+
+		for idx, option := range options {
+			newOption := option.ToDB(idx)
+			newOption.FieldID = fieldID
+
+			if option.ID == 0 {
+				if err := dao.Create(tx, &newOption); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, ok := removed[option.ID]; !ok {
+				return e.ErrInvalidParams.Wrap(fmt.Errorf("option %d not found", option.ID))
+			}
+			delete(removed, option.ID)
+
+			uniqueFields = map[string]interface{}{"id": option.ID}
+			updateFields := map[string]interface{}{
+				"value": newOption.Value,
+				"color": newOption.Color,
+				"sort":  newOption.Sort,
+			}
+			if err := dao.Update[model.FieldOption](tx, uniqueFields, updateFields); err != nil {
+				return err
+			}
+		}
+
+		for _, option := range removed {
+			// 回收站中的字段值也引用选项，恢复后需要保持可用
+			var count int64
+			err := tx.Unscoped().Model(&model.ItemFieldValue{}).Where("value_option = ?", option.ID).Count(&count).Error
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return e.ErrInvalidParams.Wrap(fmt.Errorf("option %s is in use", option.Value))
+			}
+
+			uniqueFields = map[string]interface{}{"id": option.ID}
+			if err := dao.Delete[model.FieldOption](tx, uniqueFields, false); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
 	return err
 }
 
-// RestoreField 恢复字段
-func RestoreField(fieldID uint) error {
+// 彻底删除字段的选项，需在删除字段值之后调用
+func deleteFieldOptions(tx *gorm.DB, fieldIDs []uint) error {
+	if len(fieldIDs) == 0 {
+		return nil
+	}
+
+	filters := []dao.Filter{
+		{
+			Where: "field_id IN (?)",
+			Args:  []interface{}{fieldIDs},
+		},
+	}
+	return dao.DeleteByFilter[model.FieldOption](tx, filters, false)
+}
+
+// DeleteField 删除字段
+func DeleteField(fieldID uint, userID uint) error {
 	db := conn.GetDB()
+	cfg := config.GetConfig()
+	isSoftDelete := cfg.RecycleBin.Enable
 
 	err := db.Transaction(func(tx *gorm.DB) error {
 		var uniqueFields map[string]interface{}
 		var err error
 
-		// 尝试恢复分类
+		err = checkOwner[model.Field](tx, fieldID, userID)
+		if err != nil {
+			return err
+		}
 		var field model.Field
-		err = tx.Unscoped().Model(&field).Where("id = ?", fieldID).First(&field).Error
+		err = tx.Where("id = ?", fieldID).First(&field).Error
 		if err != nil {
 			return err
 		}
-		err = tryRestoreCategory(tx, field.CategoryID)
+
+		// 删除字段值
+		uniqueFields = map[string]interface{}{"field_id": fieldID}
+		err = dao.Delete[model.ItemFieldValue](tx, uniqueFields, isSoftDelete)
 		if err != nil {
 			return err
 		}
 
-		// 恢复字段
+		// 删除字段，软删除时保留选项以便恢复
+		if !isSoftDelete {
+			err = deleteFieldOptions(tx, []uint{fieldID})
+			if err != nil {
+				return err
+			}
+		}
 		uniqueFields = map[string]interface{}{"id": fieldID}
-		err = dao.Restore[model.Field](tx, uniqueFields)
+		err = dao.Delete[model.Field](tx, uniqueFields, isSoftDelete)
 		if err != nil {
 			return err
 		}
-		return nil
+
+		// 字段值不再出现在全文索引中
+		return dao.IndexItemsByFilter(tx, categoryItemsFilter(field.CategoryID))
+	})
+
+	return err
+}
+
+// RestoreField 恢复字段
+func RestoreField(fieldID uint, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOwner[model.Field](tx, fieldID, userID); err != nil {
+			return err
+		}
+		return restoreField(tx, fieldID)
 	})
 
 	return err
 }
+
+// restoreField 恢复字段，并尝试恢复所属分类
+func restoreField(tx *gorm.DB, fieldID uint) error {
+	var uniqueFields map[string]interface{}
+	var err error
+
+	// 尝试恢复分类
+	var field model.Field
+	err = tx.Unscoped().Model(&field).Where("id = ?", fieldID).First(&field).Error
+	if err != nil {
+		return err
+	}
+	err = tryRestoreCategory(tx, field.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	// 检查是否存在同名的未删除字段
+	uniqueFields = map[string]interface{}{
+		"category_id": field.CategoryID,
+		"name":        field.Name,
+	}
+	filters := []dao.Filter{
+		{
+			Where: "id != ? AND deleted_at IS NULL",
+			Args:  []interface{}{fieldID},
+		},
+	}
+	id, _, err := dao.DuplicateCheck[model.Field](tx, uniqueFields, filters)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		return e.ErrDuplicated.Wrap(fmt.Errorf("field %s", field.Name))
+	}
+
+	// 恢复字段
+	uniqueFields = map[string]interface{}{"id": fieldID}
+	err = dao.Restore[model.Field](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
+	return dao.IndexItemsByFilter(tx, categoryItemsFilter(field.CategoryID))
+}
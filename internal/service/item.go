@@ -7,16 +7,28 @@ import (
 	"collectify/internal/model/common"
 	model "collectify/internal/model/db"
 	define "collectify/internal/model/define"
+	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/normalize"
+	"collectify/internal/pkg/query"
+	"collectify/internal/pkg/timerange"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// CreateItem 创建收藏品
+// CreateItem 创建收藏品，item.UserID 为所有者
 func CreateItem(item *model.Item, values []define.ItemFieldValue) error {
 	db := conn.GetDB()
 
+	if err := checkItemAliases(item.Aliases); err != nil {
+		return err
+	}
+	setItemNameKeys(item)
+
 	err := db.Transaction(func(tx *gorm.DB) error {
 		// 创建收藏品
 		if err := dao.Create(tx, item); err != nil {
@@ -24,8 +36,8 @@ func CreateItem(item *model.Item, values []define.ItemFieldValue) error {
 		}
 
 		// 获取分类信息，并预加载字段
-		uniqueFields := map[string]interface{}{"id": item.CategoryID}
-		preloads := []string{"Fields"}
+		uniqueFields := dao.OwnedBy(map[string]interface{}{"id": item.CategoryID}, item.UserID)
+		preloads := []string{"Fields", "Fields.Options"}
 		category, err := dao.Get[model.Category](tx, uniqueFields, preloads...)
 		if err != nil {
 			return err
@@ -51,31 +63,39 @@ func CreateItem(item *model.Item, values []define.ItemFieldValue) error {
 			}
 		}
 
-		return nil
+		return dao.IndexItems(tx, []uint{item.ID})
 	})
 
 	return err
 }
 
-// UpdateItem 更新收藏品信息
+// UpdateItem 更新收藏品信息，item.UserID 为当前用户，仅能更新属于当前用户的收藏品
 func UpdateItem(item *model.Item, values []define.ItemFieldValue) error {
 	db := conn.GetDB()
 
+	if err := checkItemAliases(item.Aliases); err != nil {
+		return err
+	}
+	setItemNameKeys(item)
+
 	err := db.Transaction(func(tx *gorm.DB) error {
-		uniqueFields := map[string]interface{}{"id": item.ID}
+		uniqueFields := dao.OwnedBy(map[string]interface{}{"id": item.ID}, item.UserID)
 		preloads := []string{
-			"Category",        // 预加载所属分类
-			"Category.Fields", // 预加载分类的字段
-			"Values",          // 预加载已有字段值
+			"Category",                // 预加载所属分类
+			"Category.Fields",         // 预加载分类的字段
+			"Category.Fields.Options", // 预加载选择字段的选项
+			"Values",                  // 预加载已有字段值
 		}
 		oldItem, err := dao.Get[model.Item](tx, uniqueFields, preloads...)
 		if err != nil {
 			return err
 		}
+		uniqueFields = map[string]interface{}{"id": item.ID}
 
 		// 更新收藏品信息
 		updateFields := map[string]interface{}{
 			"name":        item.Name,
+			"name_key":    item.NameKey,
 			"status":      item.Status,
 			"rating":      item.Rating,
 			"description": item.Description,
@@ -83,6 +103,7 @@ func UpdateItem(item *model.Item, values []define.ItemFieldValue) error {
 			"cover_url":   item.CoverURL,
 			"source_url":  item.SourceURL,
 			"priority":    item.Priority,
+			"private":     item.Private,
 		}
 
 		// 更新完成时间
@@ -127,14 +148,55 @@ func UpdateItem(item *model.Item, values []define.ItemFieldValue) error {
 			}
 		}
 
-		return nil
+		// 替换别名
+		uniqueFields = map[string]interface{}{"item_id": item.ID}
+		if err := dao.Delete[model.ItemAlias](tx, uniqueFields, false); err != nil {
+			return err
+		}
+		for _, alias := range item.Aliases {
+			alias.ItemID = item.ID
+			if err := dao.Create(tx, &alias); err != nil {
+				return err
+			}
+		}
+
+		return dao.IndexItems(tx, []uint{item.ID})
 	})
 
 	return err
 }
 
+// checkItemAliases 检查收藏品的别名，别名不能为空且不能重复（不区分大小写）
+func checkItemAliases(aliases []model.ItemAlias) error {
+	names := make(map[string]bool)
+	for _, alias := range aliases {
+		if alias.Name == "" {
+			return e.ErrInvalidParams.Wrap(errors.New("alias name is empty"))
+		}
+		name := strings.ToLower(alias.Name)
+		if names[name] {
+			return e.ErrInvalidParams.Wrap(fmt.Errorf("duplicate alias: %s", alias.Name))
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// setItemNameKeys 生成收藏品名称和别名的搜索键
+func setItemNameKeys(item *model.Item) {
+	item.NameKey = normalize.Key(item.Name)
+	for i := range item.Aliases {
+		item.Aliases[i].NameKey = normalize.Key(item.Aliases[i].Name)
+	}
+}
+
+// orphanAliasesFilter 所属收藏品已被彻底删除的别名
+var orphanAliasesFilter = dao.Filter{
+	Where: "item_id NOT IN (SELECT id FROM items)",
+}
+
 // DeleteItem 删除收藏品
-func DeleteItem(itemID uint) error {
+func DeleteItem(itemID uint, userID uint) error {
 	db := conn.GetDB()
 	cfg := config.GetConfig()
 	isSoftDelete := cfg.RecycleBin.Enable
@@ -143,6 +205,11 @@ func DeleteItem(itemID uint) error {
 		var uniqueFields map[string]interface{}
 		var err error
 
+		err = checkOwner[model.Item](tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+
 		// 删除收藏品下的字段值
 		uniqueFields = map[string]interface{}{"item_id": itemID}
 		err = dao.Delete[model.ItemFieldValue](tx, uniqueFields, isSoftDelete)
@@ -150,6 +217,20 @@ func DeleteItem(itemID uint) error {
 			return err
 		}
 
+		// 彻底删除时一并删除别名和其他收藏品关联该收藏品的字段值，放入回收站时保留以便恢复
+		if !isSoftDelete {
+			uniqueFields = map[string]interface{}{"item_id": itemID}
+			err = dao.Delete[model.ItemAlias](tx, uniqueFields, false)
+			if err != nil {
+				return err
+			}
+			uniqueFields = map[string]interface{}{"value_item": itemID}
+			err = dao.Delete[model.ItemFieldValue](tx, uniqueFields, false)
+			if err != nil {
+				return err
+			}
+		}
+
 		// 删除收藏品
 		uniqueFields = map[string]interface{}{"id": itemID}
 		err = dao.Delete[model.Item](tx, uniqueFields, isSoftDelete)
@@ -157,62 +238,141 @@ func DeleteItem(itemID uint) error {
 			return err
 		}
 
-		return nil
+		return dao.IndexItems(tx, []uint{itemID})
 	})
 
 	return err
 }
 
 // RestoreItem 恢复收藏品
-func RestoreItem(itemID uint) error {
+func RestoreItem(itemID uint, userID uint) error {
 	db := conn.GetDB()
 
 	err := db.Transaction(func(tx *gorm.DB) error {
-		var uniqueFields map[string]interface{}
-		var err error
-
-		// 尝试恢复分类和分类下的字段
-		var item model.Item
-		err = tx.Unscoped().Model(&item).Where("id = ?", itemID).First(&item).Error
-		if err != nil {
-			return err
-		}
-		err = tryRestoreCategoryWithFields(tx, item.CategoryID)
-		if err != nil {
+		if err := checkOwner[model.Item](tx, itemID, userID); err != nil {
 			return err
 		}
+		return restoreItem(tx, itemID)
+	})
 
-		// 恢复收藏品
-		uniqueFields = map[string]interface{}{"id": itemID}
-		err = dao.Restore[model.Item](tx, uniqueFields)
+	return err
+}
+
+// restoreItem 恢复收藏品及其字段值，并尝试恢复所属分类和分类下的字段
+func restoreItem(tx *gorm.DB, itemID uint) error {
+	var uniqueFields map[string]interface{}
+	var err error
+
+	// 尝试恢复分类和分类下的字段
+	var item model.Item
+	err = tx.Unscoped().Model(&item).Where("id = ?", itemID).First(&item).Error
+	if err != nil {
+		return err
+	}
+	err = tryRestoreCategoryWithFields(tx, item.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	// 恢复收藏品
+	uniqueFields = map[string]interface{}{"id": itemID}
+	err = dao.Restore[model.Item](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
+
+	// 恢复收藏品下的字段值
+	uniqueFields = map[string]interface{}{"item_id": itemID}
+	err = dao.Restore[model.ItemFieldValue](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
+
+	return dao.IndexItems(tx, []uint{itemID})
+}
+
+// AddItemTag 为收藏品添加标签，并更新收藏品的全文索引
+func AddItemTag(itemID, tagID uint, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := dao.Associate[model.Item, model.Tag](tx, itemID, tagID, "Tags", userID)
 		if err != nil {
 			return err
 		}
+		// 标签名包含在全文索引中
+		return dao.IndexItems(tx, []uint{itemID})
+	})
 
-		// 恢复收藏品下的字段值
-		uniqueFields = map[string]interface{}{"item_id": itemID}
-		err = dao.Restore[model.ItemFieldValue](tx, uniqueFields)
+	return err
+}
+
+// RemoveItemTag 移除收藏品的标签，并更新收藏品的全文索引
+func RemoveItemTag(itemID, tagID uint, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := dao.Disassociate[model.Item, model.Tag](tx, itemID, tagID, "Tags", userID)
 		if err != nil {
 			return err
 		}
-
-		return nil
+		return dao.IndexItems(tx, []uint{itemID})
 	})
 
 	return err
 }
 
-// ListItems 列出收藏品
-func ListItems(p common.Pagination) ([]model.Item, int64, error) {
+// ListItemReferences 列出通过关联字段引用了该收藏品的字段值，publicOnly 为 true 时不包含私密藏品
+func ListItemReferences(itemID uint, publicOnly bool) ([]model.ItemFieldValue, error) {
 	db := conn.GetDB()
 
-	filters := []dao.Filter{}
+	// 引用方收藏品未删除，匿名访问时还需为公开藏品
+	source := dao.Filter{
+		Where: "item_id IN (SELECT id FROM items WHERE deleted_at IS NULL)",
+	}
+	if publicOnly {
+		source = dao.Filter{
+			Where: "item_id IN (SELECT id FROM items WHERE deleted_at IS NULL AND private = ?)",
+			Args:  []interface{}{false},
+		}
+	}
+	filters := []dao.Filter{
+		{
+			Where: "value_item = ?",
+			Args:  []interface{}{itemID},
+		},
+		source,
+	}
 	orderBy := []dao.OrderBy{
 		{
-			Column: "updated_at",
-			Desc:   true,
+			Column: "item_id",
 		},
 	}
+	p := common.Pagination{Disable: true}
+
+	values, _, err := dao.GetList[model.ItemFieldValue](db, filters, orderBy, p, "Item", "Field")
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ListItems 列出收藏品，publicOnly 为 true 时不包含私密藏品，sort 的格式见 parseItemSort，不支持按自定义字段排序
+func ListItems(p common.Pagination, sort string, userID uint, publicOnly bool) ([]model.Item, int64, error) {
+	db := conn.GetDB()
+
+	orderBy, err := parseItemSort(sort, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filters := dao.OwnerFilter("", userID)
+	if publicOnly {
+		filters = append(filters, dao.Filter{
+			Where: "private = ?",
+			Args:  []interface{}{false},
+		})
+	}
 	preloads := []string{
 		"Category",
 		"Tags",
@@ -226,68 +386,69 @@ func ListItems(p common.Pagination) ([]model.Item, int64, error) {
 	return items, total, nil
 }
 
-// SearchItems 搜索收藏品
-func SearchItems(categoryID uint, name string, tagIDs []uint, collectionIDs []uint, fieldFilters map[uint]interface{}, p common.Pagination) ([]model.Item, int64, error) {
+// SearchItems 搜索收藏品，publicOnly 为 true 时不包含私密藏品
+func SearchItems(req define.SearchItemsReq, p common.Pagination, userID uint, publicOnly bool) ([]model.Item, int64, error) {
 	db := conn.GetDB()
 
-	// 预加载关联表
-	joins := []dao.Join{
-		{
-			Table: "item_tags",
-			On:    "items.id = item_tags.item_id",
-		},
-		{
-			Table: "tags",
-			On:    "item_tags.tag_id = tags.id",
-		},
-		{
-			Table: "collection_items",
-			On:    "items.id = collection_items.item_id",
-		},
-		{
-			Table: "collections",
-			On:    "collection_items.collection_id = collections.id",
-		},
-		{
-			Table: "item_field_values",
-			On:    "items.id = item_field_values.item_id",
-		},
+	// 所有条件都是作用于收藏品的列或 EXISTS 子查询，不关联其他表，可以直接排序和分页
+	filters := dao.OwnerFilter("items", userID)
+	if publicOnly {
+		filters = append(filters, dao.Filter{
+			Where: "items.private = ?",
+			Args:  []interface{}{false},
+		})
 	}
 
-	filters := []dao.Filter{}
-
 	// 筛选条件
-	if categoryID > 0 {
+	if req.CategoryID > 0 {
 		filters = append(filters, dao.Filter{
 			Where: "items.category_id = ?",
-			Args:  []interface{}{categoryID},
+			Args:  []interface{}{req.CategoryID},
 		})
 	}
-	if name != "" {
-		filters = append(filters, dao.Filter{
-			Where: "items.name LIKE ?",
-			Args:  []interface{}{"%" + name + "%"},
-		})
+	if req.Name != "" {
+		filters = append(filters, dao.ItemNameKeyFilter(req.Name))
 	}
-	if len(tagIDs) > 0 {
-		filters = append(filters, dao.Filter{
-			Where: "tags.id IN ?",
-			Args:  []interface{}{tagIDs},
-		})
+	fullText, hasText := dao.NewFullTextQuery(req.Text)
+	if hasText {
+		filters = append(filters, fullText.Filter())
 	}
-	if len(collectionIDs) > 0 {
-		filters = append(filters, dao.Filter{
-			Where: "collections.id IN ?",
-			Args:  []interface{}{collectionIDs},
-		})
+	// 标签和收藏夹按关联表的子查询筛选，多个条件可以同时使用
+	if len(req.TagIDs) > 0 {
+		filters = append(filters, dao.JoinTableFilter("item_tags", "tag_id", req.TagIDs, req.TagMatch))
+	}
+	if len(req.ExcludeTagIDs) > 0 {
+		filters = append(filters, dao.JoinTableFilter("item_tags", "tag_id", req.ExcludeTagIDs, define.MatchNone))
+	}
+	if len(req.CollectionIDs) > 0 {
+		filters = append(filters, dao.JoinTableFilter("collection_items", "collection_id", req.CollectionIDs, req.CollectionMatch))
+	}
+	if len(req.ExcludeCollectionIDs) > 0 {
+		filters = append(filters, dao.JoinTableFilter("collection_items", "collection_id", req.ExcludeCollectionIDs, define.MatchNone))
 	}
 
-	// 更新时间逆序排序
-	orderBy := []dao.OrderBy{
-		{
-			Column: "updated_at",
-			Desc:   true,
-		},
+	// 创建时间和完成时间范围
+	for _, timeFilter := range []struct {
+		column string
+		value  interface{}
+	}{{"items.created_at", req.CreatedAt}, {"items.completed_at", req.CompletedAt}} {
+		if timeFilter.value == nil {
+			continue
+		}
+		r, err := timerange.Parse(timeFilter.value, time.Now())
+		if err != nil {
+			return nil, 0, e.ErrInvalidParams.Wrap(fmt.Errorf("%s: %w", timeFilter.column, err))
+		}
+		filters = append(filters, dao.TimeRangeFilter(timeFilter.column, r))
+	}
+
+	// 查询语句在获取分类字段后编译
+	var q query.Node
+	if strings.TrimSpace(req.Q) != "" {
+		var err error
+		if q, err = query.Parse(req.Q); err != nil {
+			return nil, 0, e.ErrInvalidParams.Wrap(err)
+		}
 	}
 
 	// 预加载关联表
@@ -295,59 +456,143 @@ func SearchItems(categoryID uint, name string, tagIDs []uint, collectionIDs []ui
 		"Category",
 		"Tags",
 		"Collections",
+		"Aliases",
 		"Values",
 		"Values.Field",
+		"Values.Option",
+		"Values.Ref",
 	}
 
 	var items []model.Item
 	var total int64
 	err := db.Transaction(func(tx *gorm.DB) error {
-		if categoryID > 0 {
+		var fields []model.Field
+		fieldMap := make(map[uint]model.Field)
+		if req.CategoryID > 0 {
 			// 获取分类信息，并预加载字段
-			fieldMap := make(map[uint]model.Field)
-			category, err := dao.Get[model.Category](tx, map[string]interface{}{"id": categoryID}, "Fields")
+			uniqueFields := dao.OwnedBy(map[string]interface{}{"id": req.CategoryID}, userID)
+			category, err := dao.Get[model.Category](tx, uniqueFields, "Fields", "Fields.Options")
 			if err != nil {
 				return err
 			}
+			fields = category.Fields
 			for _, field := range category.Fields {
 				fieldMap[field.ID] = field
 			}
 
-			// 遍历并添加字段值过滤条件
-			for key, value := range fieldFilters {
+			// 遍历并添加字段值过滤条件，每个条件是独立的子查询，不同字段或同一字段的多个条件可以同时满足
+			for key, value := range req.Filters {
 				field, ok := fieldMap[key]
 				if !ok {
 					return fmt.Errorf("field not found: %d", key)
 				}
 
-				builder := dao.NewFieldValueQueryBuilder(tx, field, value)
-				filter, err := builder.Build()
-				if err != nil {
-					return err
+				for _, condition := range define.SplitFieldFilters(value) {
+					builder := dao.NewFieldValueQueryBuilder(tx, field, condition)
+					filter, err := builder.BuildExists()
+					if err != nil {
+						return err
+					}
+					filters = append(filters, filter)
 				}
-				filters = append(filters, filter)
 			}
 		}
 
-		// 先查询出所有符合条件的收藏品ID，再预加载关联表，避免笛卡尔积查询
-		itemIDs, err := dao.Pluck[model.Item, uint](tx, "items.id", joins, filters, true)
+		// 查询语句中的字段名按分类的字段解析
+		if q != nil {
+			filter, err := dao.NewQueryCompiler(tx, fields).Compile(q)
+			if err != nil {
+				return e.ErrInvalidParams.Wrap(err)
+			}
+			filters = append(filters, filter)
+		}
+
+		// 按自定义字段排序需要指定分类
+		orderBy, err := parseItemSort(req.Sort, fieldMap)
 		if err != nil {
 			return err
 		}
-
-		filters = []dao.Filter{
-			{
-				Where: "items.id IN ?",
-				Args:  []interface{}{itemIDs},
-			},
+		// 全文搜索未指定排序时按相关度排序
+		if rank, ok := fullText.Rank(); ok && strings.TrimSpace(req.Sort) == "" {
+			orderBy = append([]dao.OrderBy{rank}, orderBy...)
 		}
-		items, total, err = dao.GetList[model.Item](tx, filters, orderBy, p, preloads...)
+
+		// 在一个查询中排序、分页和计数，只为当前页的收藏品预加载关联表
+		items, total, err = dao.GetPage[model.Item](tx, filters, orderBy, p, preloads...)
 		if err != nil {
 			return err
 		}
 
+		if !hasText {
+			return nil
+		}
+		pageIDs := make([]uint, len(items))
+		for i, item := range items {
+			pageIDs[i] = item.ID
+		}
+		snippets, err := fullText.Snippets(tx, pageIDs)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].Snippet = snippets[items[i].ID]
+		}
 		return nil
 	})
 
 	return items, total, err
 }
+
+// 可排序的收藏品属性
+var itemSortColumns = map[string]string{
+	"name":         "items.name",
+	"rating":       "items.rating",
+	"priority":     "items.priority",
+	"status":       "items.status",
+	"created_at":   "items.created_at",
+	"updated_at":   "items.updated_at",
+	"completed_at": "items.completed_at",
+}
+
+// parseItemSort 解析排序参数，多个排序键以逗号分隔，- 前缀表示降序，field:<字段ID> 按 fieldMap 中的自定义字段值排序
+// 空值总是排在最后；未指定时按更新时间逆序，最后按 ID 逆序保证分页稳定
+func parseItemSort(sort string, fieldMap map[uint]model.Field) ([]dao.OrderBy, error) {
+	var orderBy []dao.OrderBy
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		desc := strings.HasPrefix(key, "-")
+		key = strings.TrimPrefix(key, "-")
+
+		if column, ok := itemSortColumns[key]; ok {
+			orderBy = append(orderBy, dao.OrderBy{Column: column, Desc: desc, NullsLast: true})
+			continue
+		}
+
+		id, ok := strings.CutPrefix(key, "field:")
+		if !ok {
+			return nil, e.ErrInvalidParams.Wrap(fmt.Errorf("unsupported sort key: %s", key))
+		}
+		fieldID, err := strconv.ParseUint(id, 10, 0)
+		if err != nil {
+			return nil, e.ErrInvalidParams.Wrap(fmt.Errorf("invalid sort field: %s", id))
+		}
+		field, ok := fieldMap[uint(fieldID)]
+		if !ok {
+			return nil, e.ErrInvalidParams.Wrap(fmt.Errorf("sort field not found: %d", fieldID))
+		}
+		column, args, err := dao.FieldValueSortColumn(field, desc)
+		if err != nil {
+			return nil, err
+		}
+		orderBy = append(orderBy, dao.OrderBy{Column: column, Desc: desc, Args: args, NullsLast: true})
+	}
+
+	if len(orderBy) == 0 {
+		orderBy = append(orderBy, dao.OrderBy{Column: "items.updated_at", Desc: true})
+	}
+	orderBy = append(orderBy, dao.OrderBy{Column: "items.id", Desc: true})
+	return orderBy, nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/password"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cast"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateType        = "oidc_state"     // OpenID Connect 登录 state 的类型
+	oidcStateTTL         = 10 * time.Minute // 跳转到身份提供方登录的有效期
+	oidcDefaultGroupsKey = "groups"
+)
+
+// 缓存身份提供方的发现文档和公钥，修改提供方地址后重新获取
+var oidcProvider struct {
+	sync.Mutex
+	issuer   string
+	provider *oidc.Provider
+}
+
+// OIDCEnabled 是否启用了 OpenID Connect 登录
+func OIDCEnabled() bool {
+	cfg := config.GetConfig().Auth
+	return cfg.Enable && cfg.OIDCIssuer != "" && cfg.OIDCClientID != ""
+}
+
+func getOIDCProvider(ctx context.Context) (*oidc.Provider, error) {
+	issuer := config.GetConfig().Auth.OIDCIssuer
+
+	oidcProvider.Lock()
+	defer oidcProvider.Unlock()
+
+	if oidcProvider.provider != nil && oidcProvider.issuer == issuer {
+		return oidcProvider.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	oidcProvider.issuer = issuer
+	oidcProvider.provider = provider
+	return provider, nil
+}
+
+func oidcOAuth2Config(provider *oidc.Provider) oauth2.Config {
+	cfg := config.GetConfig().Auth
+
+	scopes := cfg.OIDCScopes
+	if !slices.Contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	return oauth2.Config{
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// OIDCAuthURL 生成跳转到身份提供方的登录地址
+// state 为签名的短期 token，包含校验 ID Token 所需的 nonce，前端需保存 state 并在回调时比对
+func OIDCAuthURL(ctx context.Context) (string, string, error) {
+	if !OIDCEnabled() {
+		return "", "", e.ErrOIDCDisabled
+	}
+
+	provider, err := getOIDCProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := newRandomToken("")
+	if err != nil {
+		return "", "", err
+	}
+	claims := jwt.MapClaims{
+		"typ":   oidcStateType,
+		"nonce": nonce,
+		"exp":   time.Now().Add(oidcStateTTL).Unix(),
+	}
+	state, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+		SignedString([]byte(config.GetConfig().Auth.JwtSecret))
+	if err != nil {
+		return "", "", err
+	}
+
+	oauth2Config := oidcOAuth2Config(provider)
+	return oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce)), state, nil
+}
+
+// OIDCLogin 使用身份提供方回调的授权码完成登录并记录审计日志
+// 账号首次登录时自动创建本地用户，不会与同名的本地用户绑定
+func OIDCLogin(ctx context.Context, code, state string, client LoginClient) (model.User, error) {
+	if !OIDCEnabled() {
+		return model.User{}, e.ErrOIDCDisabled
+	}
+
+	nonce, err := parseOIDCState(state)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	provider, err := getOIDCProvider(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	oauth2Config := oidcOAuth2Config(provider)
+	token, err := oauth2Config.Exchange(ctx, code)
+	if err != nil {
+		return model.User{}, e.ErrUnauthorized.Wrap(err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return model.User{}, e.ErrUnauthorized.Wrap(errors.New("missing id_token"))
+	}
+
+	verifier := provider.Verifier(&oidc.Config{ClientID: oauth2Config.ClientID})
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return model.User{}, e.ErrUnauthorized.Wrap(err)
+	}
+	if idToken.Nonce != nonce {
+		return model.User{}, e.ErrUnauthorized.Wrap(errors.New("nonce mismatch"))
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return model.User{}, e.ErrUnauthorized.Wrap(err)
+	}
+	username := oidcUsername(idToken.Subject, claims)
+
+	user, err := oidcUser(idToken.Issuer, idToken.Subject, username, claims)
+	result := model.LoginResultSuccess
+	if err != nil {
+		result = model.LoginResultFailure
+	} else {
+		username = user.Username
+		if user.TOTPEnabled {
+			result = model.LoginResultChallenge
+		}
+	}
+	if recordErr := recordLogin(user.ID, username, client, result, err); recordErr != nil {
+		return model.User{}, recordErr
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+func parseOIDCState(state string) (string, error) {
+	token, err := jwt.Parse(state, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.GetConfig().Auth.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", e.ErrUnauthorized.Wrap(err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != oidcStateType {
+		return "", e.ErrUnauthorized
+	}
+	return cast.ToString(claims["nonce"]), nil
+}
+
+// 依次使用 preferred_username、email 和 sub 作为用户名
+func oidcUsername(subject string, claims map[string]interface{}) string {
+	for _, key := range []string{"preferred_username", "email"} {
+		if username := cast.ToString(claims[key]); username != "" {
+			return username
+		}
+	}
+	return subject
+}
+
+// 检查用户组并返回绑定的本地用户，首次登录时创建用户
+func oidcUser(issuer, subject, username string, claims map[string]interface{}) (model.User, error) {
+	cfg := config.GetConfig().Auth
+
+	if len(cfg.OIDCAllowedGroups) > 0 {
+		groupsClaim := cfg.OIDCGroupsClaim
+		if groupsClaim == "" {
+			groupsClaim = oidcDefaultGroupsKey
+		}
+		groups := cast.ToStringSlice(claims[groupsClaim])
+		allowed := slices.ContainsFunc(groups, func(group string) bool {
+			return slices.Contains(cfg.OIDCAllowedGroups, group)
+		})
+		if !allowed {
+			return model.User{}, e.ErrForbidden.Wrap(errors.New("user is not in an allowed group"))
+		}
+	}
+
+	var user model.User
+	err := conn.GetDB().Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"issuer": issuer, "subject": subject}
+		identity, err := dao.Get[model.UserIdentity](tx, uniqueFields)
+		if err == nil {
+			user, err = getUser(tx, identity.UserID)
+			if err != nil {
+				return err
+			}
+			if user.Disabled {
+				return e.ErrUserDisabled
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		user, err = createOIDCUser(tx, username)
+		if err != nil {
+			return err
+		}
+		identity = model.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  issuer,
+			Subject: subject,
+		}
+		return dao.Create(tx, &identity)
+	})
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// 创建通过身份提供方登录的用户，用户名已被占用时添加数字后缀
+// 密码为随机值，用户只能通过身份提供方登录
+func createOIDCUser(tx *gorm.DB, username string) (model.User, error) {
+	role := config.GetConfig().Auth.OIDCDefaultRole
+	if role == 0 {
+		role = model.UserRoleEditor
+	}
+
+	candidate := username
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&model.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return model.User{}, err
+		}
+		if count == 0 {
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d", username, i)
+	}
+
+	plain, err := newRandomToken("")
+	if err != nil {
+		return model.User{}, err
+	}
+	hash, err := password.Hash(plain)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user := model.User{
+		Username: candidate,
+		Password: hash,
+		Role:     role,
+	}
+	if err := dao.Create(tx, &user); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
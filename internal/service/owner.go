@@ -0,0 +1,40 @@
+package service
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/conn"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// PublicOwnerID 返回匿名访问和未启用认证时访问的藏品库所有者ID
+// 为 AUTH_PUBLIC_LIBRARY 指定的用户的藏品库，未指定时为最早创建的管理员的藏品库
+func PublicOwnerID() (uint, error) {
+	var user model.User
+	query := conn.GetDB().Model(&user)
+	if username := config.GetConfig().Auth.PublicLibrary; username != "" {
+		query = query.Where("username = ?", username)
+	} else {
+		query = query.Where("role = ?", model.UserRoleAdmin).Order("id")
+	}
+	if err := query.First(&user).Error; err != nil {
+		return 0, err
+	}
+	return user.OwnerID(), nil
+}
+
+// checkOwner 检查记录（包括已删除的记录）是否属于用户
+func checkOwner[T model.GormModel](tx *gorm.DB, id uint, userID uint) error {
+	var t T
+	err := tx.Unscoped().Model(&t).Where("id = ? AND user_id = ?", id, userID).First(&t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
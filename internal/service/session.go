@@ -0,0 +1,201 @@
+package service
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const refreshTokenPrefix = "clr_" // 刷新令牌前缀
+
+// TokenPair 访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // 访问令牌有效期
+}
+
+// GenerateToken 生成与会话绑定的 JWT，expire 为 0 时不过期
+func GenerateToken(user model.User, sessionID uint, expire time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      sessionID,
+	}
+
+	// 如果过期时间大于 0，则设置过期时间
+	if expire > 0 {
+		claims["exp"] = time.Now().Add(expire).Unix()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(config.GetConfig().Auth.JwtSecret))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+// 会话有效期，0 表示永不过期
+func sessionLifetime() time.Duration {
+	return time.Duration(config.GetConfig().Auth.ExpireDay) * time.Hour * 24
+}
+
+// 创建会话，refreshHash 为空时会话不支持刷新
+func createSession(tx *gorm.DB, userID uint, refreshHash string) (model.Session, error) {
+	now := time.Now()
+
+	// 顺便清理该用户已过期的会话
+	filters := []dao.Filter{
+		{
+			Where: "user_id = ? AND expires_at IS NOT NULL AND expires_at <= ?",
+			Args:  []interface{}{userID, now},
+		},
+	}
+	if err := dao.DeleteByFilter[model.Session](tx, filters, false); err != nil {
+		return model.Session{}, err
+	}
+
+	session := model.Session{
+		UserID:      userID,
+		RefreshHash: refreshHash,
+	}
+	if lifetime := sessionLifetime(); lifetime > 0 {
+		expiresAt := now.Add(lifetime)
+		session.ExpiresAt = &expiresAt
+	}
+	if err := dao.Create(tx, &session); err != nil {
+		return model.Session{}, err
+	}
+	return session, nil
+}
+
+// IssueLoginToken 为登录创建会话并签发 JWT，有效期与会话相同
+func IssueLoginToken(user model.User) (string, error) {
+	session, err := createSession(conn.GetDB(), user.ID, "")
+	if err != nil {
+		return "", err
+	}
+	return GenerateToken(user, session.ID, sessionLifetime())
+}
+
+// IssueTokenPair 创建会话并签发短期访问令牌和刷新令牌
+func IssueTokenPair(user model.User) (TokenPair, error) {
+	refreshToken, err := newRandomToken(refreshTokenPrefix)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	session, err := createSession(conn.GetDB(), user.ID, hashToken(refreshToken))
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return newTokenPair(user, session.ID, refreshToken)
+}
+
+func newTokenPair(user model.User, sessionID uint, refreshToken string) (TokenPair, error) {
+	ttl := config.GetConfig().Auth.AccessTokenTTL
+	accessToken, err := GenerateToken(user, sessionID, ttl)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    ttl,
+	}, nil
+}
+
+// RefreshTokenPair 使用刷新令牌换取新的令牌对，旧的刷新令牌随即失效
+func RefreshTokenPair(refreshToken string) (model.User, TokenPair, error) {
+	db := conn.GetDB()
+
+	var user model.User
+	var pair TokenPair
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"refresh_hash": hashToken(refreshToken)}
+		session, err := dao.Get[model.Session](tx, uniqueFields)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e.ErrUnauthorized
+			}
+			return err
+		}
+		if session.IsExpired(time.Now()) {
+			return e.ErrUnauthorized
+		}
+
+		uniqueFields = map[string]interface{}{"id": session.UserID}
+		user, err = dao.Get[model.User](tx, uniqueFields)
+		if err != nil || user.Disabled {
+			return e.ErrUnauthorized
+		}
+
+		// 轮换刷新令牌
+		newRefreshToken, err := newRandomToken(refreshTokenPrefix)
+		if err != nil {
+			return err
+		}
+		uniqueFields = map[string]interface{}{"id": session.ID}
+		updateFields := map[string]interface{}{"refresh_hash": hashToken(newRefreshToken)}
+		if err := dao.Update[model.Session](tx, uniqueFields, updateFields); err != nil {
+			return err
+		}
+
+		pair, err = newTokenPair(user, session.ID, newRefreshToken)
+		return err
+	})
+
+	return user, pair, err
+}
+
+// CheckSession 校验 JWT 绑定的会话是否仍然有效
+func CheckSession(sessionID, userID uint) error {
+	uniqueFields := map[string]interface{}{"id": sessionID, "user_id": userID}
+	session, err := dao.Get[model.Session](conn.GetDB(), uniqueFields)
+	if err != nil {
+		return e.ErrUnauthorized
+	}
+	if session.IsExpired(time.Now()) {
+		return e.ErrUnauthorized
+	}
+	return nil
+}
+
+// RevokeSession 吊销会话，对应的 JWT 和刷新令牌随即失效
+func RevokeSession(sessionID, userID uint) error {
+	uniqueFields := map[string]interface{}{"id": sessionID, "user_id": userID}
+	return dao.Delete[model.Session](conn.GetDB(), uniqueFields, false)
+}
+
+// RevokeUserSessions 吊销用户除 exceptSessionID 外的所有会话，exceptSessionID 为 0 时全部吊销
+func RevokeUserSessions(userID, exceptSessionID uint) error {
+	return revokeUserSessions(conn.GetDB(), userID, exceptSessionID)
+}
+
+func revokeUserSessions(tx *gorm.DB, userID, exceptSessionID uint) error {
+	filters := []dao.Filter{
+		{
+			Where: "user_id = ?",
+			Args:  []interface{}{userID},
+		},
+	}
+	if exceptSessionID != 0 {
+		filters = append(filters, dao.Filter{
+			Where: "id != ?",
+			Args:  []interface{}{exceptSessionID},
+		})
+	}
+	return dao.DeleteByFilter[model.Session](tx, filters, false)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// RestoreTag 恢复标签
+func RestoreTag(tagID uint, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkOwner[model.Tag](tx, tagID, userID); err != nil {
+			return err
+		}
+		return restoreTag(tx, tagID)
+	})
+
+	return err
+}
+
+// restoreTag 恢复标签，存在同名的未删除标签时无法恢复
+func restoreTag(tx *gorm.DB, tagID uint) error {
+	var tag model.Tag
+	err := tx.Unscoped().Model(&tag).Where("id = ?", tagID).First(&tag).Error
+	if err != nil {
+		return err
+	}
+
+	uniqueFields := map[string]interface{}{
+		"user_id": tag.UserID,
+		"name":    tag.Name,
+	}
+	filters := []dao.Filter{
+		{
+			Where: "id != ? AND deleted_at IS NULL",
+			Args:  []interface{}{tagID},
+		},
+	}
+	id, _, err := dao.DuplicateCheck[model.Tag](tx, uniqueFields, filters)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		return e.ErrDuplicated.Wrap(fmt.Errorf("tag %s", tag.Name))
+	}
+
+	uniqueFields = map[string]interface{}{"id": tagID}
+	err = dao.Restore[model.Tag](tx, uniqueFields)
+	if err != nil {
+		return err
+	}
+	return dao.IndexItemsByFilter(tx, tagItemsFilter(tagID))
+}
+
+// IndexTagItems 更新使用了该标签的收藏品的全文索引，标签改名或删除后调用
+func IndexTagItems(tagID uint) error {
+	return dao.IndexItemsByFilter(conn.GetDB(), tagItemsFilter(tagID))
+}
+
+// tagItemsFilter 使用了该标签的收藏品，用于更新全文索引
+func tagItemsFilter(tagID uint) dao.Filter {
+	return dao.Filter{
+		Where: "items.id IN (SELECT item_id FROM item_tags WHERE tag_id = ?)",
+		Args:  []interface{}{tagID},
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	"collectify/internal/model/common"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	apiTokenPrefix       = "clt_"      // 个人访问令牌前缀，用于和 JWT 区分
+	apiTokenDisplayLen   = 12          // 保存的令牌前缀长度
+	apiTokenUsedInterval = time.Minute // 最后使用时间的更新间隔，避免每次请求都写库
+)
+
+// IsAPIToken 判断是否为个人访问令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// 生成带前缀的随机令牌
+func newRandomToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// 令牌为高熵随机值，使用 SHA-256 哈希存储即可按哈希查找
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken 创建个人访问令牌，明文令牌仅在创建时返回
+func CreateAPIToken(userID uint, name, scope string) (model.APIToken, string, error) {
+	plain, err := newRandomToken(apiTokenPrefix)
+	if err != nil {
+		return model.APIToken{}, "", err
+	}
+
+	token := model.APIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(plain),
+		Prefix:    plain[:apiTokenDisplayLen],
+		Scope:     scope,
+	}
+	if err := dao.Create(conn.GetDB(), &token); err != nil {
+		return model.APIToken{}, "", err
+	}
+	return token, plain, nil
+}
+
+// ListAPITokens 列出用户的个人访问令牌
+func ListAPITokens(userID uint) ([]model.APIToken, error) {
+	filters := []dao.Filter{
+		{
+			Where: "user_id = ?",
+			Args:  []interface{}{userID},
+		},
+	}
+	orderBy := []dao.OrderBy{
+		{
+			Column: "created_at",
+			Desc:   true,
+		},
+	}
+	tokens, _, err := dao.GetList[model.APIToken](conn.GetDB(), filters, orderBy, common.Pagination{Disable: true})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteAPIToken 吊销个人访问令牌
+func DeleteAPIToken(id, userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"id": id, "user_id": userID}
+		if _, err := dao.Get[model.APIToken](tx, uniqueFields); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e.ErrNotFound
+			}
+			return err
+		}
+		return dao.Delete[model.APIToken](tx, uniqueFields, false)
+	})
+
+	return err
+}
+
+// revokeAPITokens 吊销用户的所有个人访问令牌
+func revokeAPITokens(tx *gorm.DB, userID uint) error {
+	uniqueFields := map[string]interface{}{"user_id": userID}
+	return dao.Delete[model.APIToken](tx, uniqueFields, false)
+}
+
+// AuthenticateAPIToken 校验个人访问令牌，返回令牌及所属用户
+func AuthenticateAPIToken(plain string) (model.APIToken, model.User, error) {
+	db := conn.GetDB()
+
+	uniqueFields := map[string]interface{}{"token_hash": hashToken(plain)}
+	token, err := dao.Get[model.APIToken](db, uniqueFields)
+	if err != nil {
+		return model.APIToken{}, model.User{}, e.ErrUnauthorized
+	}
+
+	uniqueFields = map[string]interface{}{"id": token.UserID}
+	user, err := dao.Get[model.User](db, uniqueFields)
+	if err != nil {
+		return model.APIToken{}, model.User{}, e.ErrUnauthorized
+	}
+
+	// 更新最后使用时间
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenUsedInterval {
+		err = db.Model(&token).UpdateColumn("last_used_at", now).Error
+		if err != nil {
+			return model.APIToken{}, model.User{}, err
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, user, nil
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/password"
+	"collectify/internal/pkg/totp"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cast"
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer         = "Collectify"
+	totpChallengeType  = "totp_challenge" // 两步验证挑战 token 的类型
+	totpChallengeTTL   = 5 * time.Minute  // 两步验证挑战的有效期
+	recoveryCodeCount  = 10               // 恢复码数量
+	recoveryCodeLength = 10               // 恢复码长度，不含分隔符
+)
+
+// 两步验证完成后签发的凭证类型
+const (
+	LoginPurposeToken     = "token"      // 登录 token
+	LoginPurposeTokenPair = "token_pair" // 访问令牌和刷新令牌
+)
+
+// SetupTOTP 生成待确认的验证器密钥，返回密钥和用于生成二维码的链接
+func SetupTOTP(userID uint) (string, string, error) {
+	db := conn.GetDB()
+
+	var secret, uri string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabled {
+			return e.ErrTOTPEnabled
+		}
+
+		secret, err = totp.GenerateSecret()
+		if err != nil {
+			return err
+		}
+		uri = totp.URI(totpIssuer, user.Username, secret)
+
+		uniqueFields := map[string]interface{}{"id": userID}
+		updateFields := map[string]interface{}{"totp_secret": secret}
+		return dao.Update[model.User](tx, uniqueFields, updateFields)
+	})
+
+	return secret, uri, err
+}
+
+// EnableTOTP 使用验证码确认密钥并启用两步验证，返回仅显示一次的恢复码
+func EnableTOTP(userID uint, code string) ([]string, error) {
+	db := conn.GetDB()
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabled {
+			return e.ErrTOTPEnabled
+		}
+		if user.TOTPSecret == "" {
+			return e.ErrTOTPNotEnabled
+		}
+
+		counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), 0)
+		if !ok {
+			return e.ErrTOTPInvalidCode
+		}
+
+		var hashes []string
+		codes, hashes, err = generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+
+		uniqueFields := map[string]interface{}{"id": userID}
+		updateFields := map[string]interface{}{
+			"totp_enabled":        true,
+			"totp_last_counter":   counter,
+			"totp_recovery_codes": strings.Join(hashes, ","),
+		}
+		return dao.Update[model.User](tx, uniqueFields, updateFields)
+	})
+
+	return codes, err
+}
+
+// DisableTOTP 停用两步验证，需校验当前密码和验证码（或恢复码）
+func DisableTOTP(userID uint, currentPassword, code string) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TOTPEnabled {
+			return e.ErrTOTPNotEnabled
+		}
+
+		ok, err := password.Verify(user.Password, currentPassword)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return e.ErrUserWrongPassword
+		}
+
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+		return resetTOTP(tx, userID)
+	})
+
+	return err
+}
+
+// ResetTOTP 管理员为丢失验证器和恢复码的用户重置两步验证
+func ResetTOTP(userID uint) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := getUser(tx, userID); err != nil {
+			return err
+		}
+		return resetTOTP(tx, userID)
+	})
+
+	return err
+}
+
+func resetTOTP(tx *gorm.DB, userID uint) error {
+	uniqueFields := map[string]interface{}{"id": userID}
+	updateFields := map[string]interface{}{
+		"totp_secret":         "",
+		"totp_enabled":        false,
+		"totp_last_counter":   0,
+		"totp_recovery_codes": "",
+	}
+	return dao.Update[model.User](tx, uniqueFields, updateFields)
+}
+
+// NewLoginChallenge 为已通过密码校验、启用了两步验证的用户生成登录挑战
+// purpose 为完成验证后签发的凭证类型
+func NewLoginChallenge(user model.User, purpose string) (string, error) {
+	claims := jwt.MapClaims{
+		"typ":     totpChallengeType,
+		"id":      user.ID,
+		"purpose": purpose,
+		"exp":     time.Now().Add(totpChallengeTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.GetConfig().Auth.JwtSecret))
+}
+
+// VerifyLoginChallenge 使用验证码或恢复码完成登录挑战，返回用户和待签发的凭证类型
+func VerifyLoginChallenge(challenge, code string, client LoginClient) (model.User, string, error) {
+	token, err := jwt.Parse(challenge, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.GetConfig().Auth.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return model.User{}, "", e.ErrUnauthorized
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != totpChallengeType {
+		return model.User{}, "", e.ErrUnauthorized
+	}
+	purpose := cast.ToString(claims["purpose"])
+
+	db := conn.GetDB()
+	user, err := getUser(db, cast.ToUint(claims["id"]))
+	if err != nil || user.Disabled || !user.TOTPEnabled {
+		return model.User{}, "", e.ErrUnauthorized
+	}
+
+	if err := checkLoginLocked(user.ID, user.Username, client); err != nil {
+		return model.User{}, "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 在事务中重新读取，使用最新的计数器和恢复码校验
+		user, err := getUser(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		if !user.TOTPEnabled {
+			return e.ErrTOTPNotEnabled
+		}
+		return verifySecondFactor(tx, user, code)
+	})
+	result := model.LoginResultSuccess
+	if err != nil {
+		result = model.LoginResultFailure
+	}
+	if recordErr := recordLogin(user.ID, user.Username, client, result, err); recordErr != nil {
+		return model.User{}, "", recordErr
+	}
+	if err != nil {
+		return model.User{}, "", err
+	}
+
+	return user, purpose, nil
+}
+
+// 校验验证码，验证码无效时尝试作为恢复码使用，恢复码使用后失效
+// 按读取时的计数器和恢复码条件更新，并发请求使用同一验证码或恢复码时只有一个成功
+func verifySecondFactor(tx *gorm.DB, user model.User, code string) error {
+	var filters []dao.Filter
+	var updateFields map[string]interface{}
+
+	if counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter); ok {
+		filters = []dao.Filter{
+			{
+				Where: "id = ? AND totp_last_counter < ?",
+				Args:  []interface{}{user.ID, counter},
+			},
+		}
+		updateFields = map[string]interface{}{"totp_last_counter": counter}
+	} else {
+		codeHash := hashToken(normalizeRecoveryCode(code))
+		hashes := strings.Split(user.TOTPRecoveryCodes, ",")
+		idx := slices.Index(hashes, codeHash)
+		if idx < 0 {
+			return e.ErrTOTPInvalidCode
+		}
+		remaining := append(hashes[:idx:idx], hashes[idx+1:]...)
+		filters = []dao.Filter{
+			{
+				Where: "id = ? AND totp_recovery_codes = ?",
+				Args:  []interface{}{user.ID, user.TOTPRecoveryCodes},
+			},
+		}
+		updateFields = map[string]interface{}{"totp_recovery_codes": strings.Join(remaining, ",")}
+	}
+
+	rows, err := dao.UpdateByFilter[model.User](tx, filters, updateFields)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return e.ErrTOTPInvalidCode
+	}
+	return nil
+}
+
+// 生成恢复码及其哈希，恢复码格式为 xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == recoveryCodeLength/2 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(alphabet[int(b)%len(alphabet)])
+		}
+		codes[i] = sb.String()
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// 忽略恢复码的大小写、空格和分隔符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func getUser(tx *gorm.DB, userID uint) (model.User, error) {
+	uniqueFields := map[string]interface{}{"id": userID}
+	user, err := dao.Get[model.User](tx, uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.User{}, e.ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return user, nil
+}
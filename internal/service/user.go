@@ -1,31 +1,263 @@
 package service
 
 import (
-	"collectify/internal/config"
+	"collectify/internal/conn"
+	"collectify/internal/dao"
 	model "collectify/internal/model/db"
-	"time"
+	"collectify/internal/pkg/e"
+	"collectify/internal/pkg/password"
+	"errors"
 
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-// 生成 token
-func GenerateToken(user model.User, expire time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"role":     user.Role,
+// Login 校验用户名和密码并记录审计日志，旧版本的明文密码校验通过后升级为哈希存储
+// 用户不存在和密码错误返回相同的错误，连续失败次数过多时锁定一段时间
+// 启用两步验证的用户还需调用 VerifyLoginChallenge 完成登录
+func Login(username, plain string, client LoginClient) (model.User, error) {
+	if err := checkLoginLocked(0, username, client); err != nil {
+		return model.User{}, err
 	}
 
-	// 如果过期时间大于 0，则设置过期时间
-	if expire > 0 {
-		claims["exp"] = time.Now().Add(expire).Unix()
+	user, err := login(username, plain)
+	result := model.LoginResultSuccess
+	if err != nil {
+		result = model.LoginResultFailure
+	} else if user.TOTPEnabled {
+		result = model.LoginResultChallenge
+	}
+	if recordErr := recordLogin(user.ID, username, client, result, err); recordErr != nil {
+		return model.User{}, recordErr
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// 校验用户名和密码，密码错误时仍返回用户以便记录审计日志
+func login(username, plain string) (model.User, error) {
+	db := conn.GetDB()
+
+	uniqueFields := map[string]interface{}{"username": username}
+	user, err := dao.Get[model.User](db, uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			password.Burn(plain)
+			return model.User{}, e.ErrUserInvalidPassword
+		}
+		return model.User{}, err
+	}
+
+	ok, err := password.Verify(user.Password, plain)
+	if err != nil {
+		return model.User{}, err
+	}
+	if !ok {
+		return user, e.ErrUserInvalidPassword
+	}
+	if user.Disabled {
+		return user, e.ErrUserDisabled
+	}
+
+	if !password.IsHashed(user.Password) {
+		hash, err := password.Hash(plain)
+		if err != nil {
+			return model.User{}, err
+		}
+
+		updateFields := map[string]interface{}{"password": hash}
+		// 仍在使用初始密码的管理员账号需要修改密码
+		if user.Username == model.DefaultAdminUsername && plain == model.DefaultAdminPassword {
+			updateFields["must_change_password"] = true
+			user.MustChangePassword = true
+		}
+
+		uniqueFields = map[string]interface{}{"id": user.ID}
+		if err := dao.Update[model.User](db, uniqueFields, updateFields); err != nil {
+			return model.User{}, err
+		}
+		user.Password = hash
+	}
+
+	return user, nil
+}
+
+// UpdateUser 更新用户名和密码，需校验当前密码
+// 用户名或密码变更后吊销除 sessionID 外的所有会话，密码变更后同时吊销所有个人访问令牌
+func UpdateUser(userID, sessionID uint, username, currentPassword, newPassword string) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"id": userID}
+		user, err := dao.Get[model.User](tx, uniqueFields)
+		if err != nil {
+			return err
+		}
+
+		ok, err := password.Verify(user.Password, currentPassword)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return e.ErrUserWrongPassword
+		}
+
+		// 需要修改密码时必须设置新密码
+		if user.MustChangePassword && (newPassword == "" || newPassword == currentPassword) {
+			return e.ErrPasswordChangeRequired
+		}
+
+		updateFields := map[string]interface{}{
+			"username": username,
+		}
+
+		// 如果密码不为空，则更新密码
+		if newPassword != "" {
+			hash, err := password.Hash(newPassword)
+			if err != nil {
+				return err
+			}
+			updateFields["password"] = hash
+			updateFields["must_change_password"] = false
+		}
+
+		if err := dao.Update[model.User](tx, uniqueFields, updateFields); err != nil {
+			return err
+		}
+
+		if newPassword != "" {
+			if err := revokeAPITokens(tx, userID); err != nil {
+				return err
+			}
+		}
+		if username != user.Username || newPassword != "" {
+			return revokeUserSessions(tx, userID, sessionID)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// CreateUser 创建用户，用户首次登录后需要修改密码
+// libraryID 不为 0 时用户共享该用户的藏品库
+func CreateUser(username, plain string, role int, libraryID uint) (model.User, error) {
+	hash, err := password.Hash(plain)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user := model.User{
+		Username:           username,
+		Password:           hash,
+		Role:               role,
+		LibraryID:          libraryID,
+		MustChangePassword: true,
+	}
+	err = conn.GetDB().Transaction(func(tx *gorm.DB) error {
+		if libraryID != 0 {
+			if err := checkLibraryOwner(tx, libraryID); err != nil {
+				return err
+			}
+		}
+		return dao.Create(tx, &user)
+	})
+	if err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// 共享的藏品库必须属于一个使用自己藏品库的用户
+func checkLibraryOwner(tx *gorm.DB, libraryID uint) error {
+	uniqueFields := map[string]interface{}{"id": libraryID}
+	owner, err := dao.Get[model.User](tx, uniqueFields)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrUserNotFound
+		}
+		return err
+	}
+	if owner.LibraryID != 0 {
+		return e.ErrInvalidParams.Wrap(errors.New("library owner shares another library"))
 	}
+	return nil
+}
+
+// SetUserRole 修改用户角色，至少保留一个可用的管理员
+func SetUserRole(userID uint, role int) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"id": userID}
+		user, err := dao.Get[model.User](tx, uniqueFields)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e.ErrUserNotFound
+			}
+			return err
+		}
+
+		if role != model.UserRoleAdmin && user.Role == model.UserRoleAdmin && !user.Disabled {
+			if err := checkLastAdmin(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		updateFields := map[string]interface{}{"role": role}
+		return dao.Update[model.User](tx, uniqueFields, updateFields)
+	})
+
+	return err
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.GetConfig().Auth.JwtSecret))
+// 检查除指定用户外是否还有可用的管理员
+func checkLastAdmin(tx *gorm.DB, userID uint) error {
+	var count int64
+	err := tx.Model(&model.User{}).
+		Where("role = ? AND disabled = ? AND id != ?", model.UserRoleAdmin, false, userID).
+		Count(&count).Error
 	if err != nil {
-		return "", err
+		return err
 	}
+	if count == 0 {
+		return e.ErrForbidden.Wrap(errors.New("cannot remove the last admin"))
+	}
+	return nil
+}
+
+// SetUserDisabled 停用或启用用户，至少保留一个可用的管理员
+func SetUserDisabled(userID uint, disabled bool) error {
+	db := conn.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uniqueFields := map[string]interface{}{"id": userID}
+		user, err := dao.Get[model.User](tx, uniqueFields)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return e.ErrUserNotFound
+			}
+			return err
+		}
+
+		if disabled && user.Role == model.UserRoleAdmin && !user.Disabled {
+			if err := checkLastAdmin(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		updateFields := map[string]interface{}{"disabled": disabled}
+		if err := dao.Update[model.User](tx, uniqueFields, updateFields); err != nil {
+			return err
+		}
+
+		// 停用后吊销所有会话
+		if disabled {
+			return revokeUserSessions(tx, userID, 0)
+		}
+		return nil
+	})
 
-	return tokenString, nil
+	return err
 }
@@ -92,6 +92,11 @@ func TestMain(m *testing.M) {
 
 // Helper function to create a test HTTP request and record the response.
 func performRequest(method, target string, body interface{}) *httptest.ResponseRecorder {
+	return performAuthRequest(method, target, body, "")
+}
+
+// Helper function to create a test HTTP request carrying an Authorization header.
+func performAuthRequest(method, target string, body interface{}, token string) *httptest.ResponseRecorder {
 	var bodyBytes []byte
 	var err error
 	if body != nil {
@@ -101,10 +106,13 @@ func performRequest(method, target string, body interface{}) *httptest.ResponseR
 		}
 	}
 
-	req := httptest.NewRequest(method, target, bytes.NewBuffer(bodyBytes))
+	req := httptest.NewRequest(method, "/api"+target, bytes.NewBuffer(bodyBytes))
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
 	w := httptest.NewRecorder()
 	testRouter.ServeHTTP(w, req)
 	return w
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aliasResponse struct {
+	CommonResponse
+	Data struct {
+		Aliases []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"aliases"`
+	} `json:"data"`
+}
+
+// --- Item Alias Tests ---
+
+func TestItemAliases(t *testing.T) {
+	category := createTestCategory(t, "Alias Shelf")
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item": map[string]interface{}{
+			"name":   "进击的巨人",
+			"status": model.ItemStatusTodo,
+			"aliases": []map[string]interface{}{
+				{"name": "Attack on Titan", "type": model.ItemAliasTypeTranslated},
+				{"name": "Shingeki no Kyojin", "type": model.ItemAliasTypeRomanized},
+				{"name": "進撃の巨人"},
+			},
+		},
+	}, ""))
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "进击的巨人").First(&item).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Alias Other", "status": model.ItemStatusTodo},
+	}, ""))
+
+	// 1. Aliases are returned in order, with other as the default type
+	getAliases := func() aliasResponse {
+		w := performRequest("GET", fmt.Sprintf("/item/%d", item.ID), nil)
+		var resp aliasResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, handler.SuccessCode, resp.Code)
+		return resp
+	}
+	resp := getAliases()
+	require.Len(t, resp.Data.Aliases, 3)
+	assert.Equal(t, "Attack on Titan", resp.Data.Aliases[0].Name)
+	assert.Equal(t, model.ItemAliasTypeRomanized, resp.Data.Aliases[1].Type)
+	assert.Equal(t, model.ItemAliasTypeOther, resp.Data.Aliases[2].Type)
+
+	// 2. Name filters, the query language and full-text search match aliases
+	want := []string{"进击的巨人"}
+	assert.Equal(t, want, searchNames(t, map[string]interface{}{"category_id": category.ID, "name": "titan"}))
+	assert.Equal(t, want, searchNames(t, map[string]interface{}{"category_id": category.ID, "q": "kyojin"}))
+	assert.Equal(t, want, searchNames(t, map[string]interface{}{"category_id": category.ID, "q": `name="進撃の巨人"`}))
+	assert.Equal(t, []string{"Alias Other"}, searchNames(t, map[string]interface{}{"category_id": category.ID, "q": `name!="Attack on Titan"`}))
+	assert.Equal(t, want, searchNames(t, map[string]interface{}{"category_id": category.ID, "text": "shingeki"}))
+
+	// 3. Updating replaces the aliases
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", item.ID), map[string]interface{}{
+		"id": item.ID,
+		"item": map[string]interface{}{
+			"name":    item.Name,
+			"status":  model.ItemStatusTodo,
+			"aliases": []map[string]interface{}{{"name": "AoT", "type": model.ItemAliasTypeOther}},
+		},
+	}, ""))
+	resp = getAliases()
+	require.Len(t, resp.Data.Aliases, 1)
+	assert.Equal(t, "AoT", resp.Data.Aliases[0].Name)
+	assert.Empty(t, searchNames(t, map[string]interface{}{"category_id": category.ID, "name": "titan"}))
+	assert.Empty(t, searchNames(t, map[string]interface{}{"category_id": category.ID, "text": "shingeki"}))
+	assert.Equal(t, want, searchNames(t, map[string]interface{}{"category_id": category.ID, "name": "aot"}))
+
+	// 4. Invalid aliases are rejected
+	invalid := [][]map[string]interface{}{
+		{{"name": "Same"}, {"name": "same"}},
+		{{"name": "  "}},
+		{{"name": "Typed", "type": "nickname"}},
+	}
+	for _, aliases := range invalid {
+		code := requestCode(t, "PUT", fmt.Sprintf("/item/%d", item.ID), map[string]interface{}{
+			"id":   item.ID,
+			"item": map[string]interface{}{"name": item.Name, "status": model.ItemStatusTodo, "aliases": aliases},
+		}, "")
+		assert.Equal(t, handler.FailCode, code, aliases)
+	}
+	assert.Len(t, getAliases().Data.Aliases, 1)
+}
@@ -0,0 +1,154 @@
+package handler_test
+
+import (
+	"bytes"
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loginAuditResponse struct {
+	CommonResponse
+	Data struct {
+		List []struct {
+			Username string `json:"username"`
+			IP       string `json:"ip"`
+			Result   string `json:"result"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	} `json:"data"`
+}
+
+// loginFrom logs in from the given client IP.
+func loginFrom(t *testing.T, username, password, ip string) loginResponse {
+	body, err := json.Marshal(map[string]string{
+		"username": username,
+		"password": password,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/user/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+
+	var resp loginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// ageLoginAudits moves the recorded login attempts of a user back in time.
+func ageLoginAudits(t *testing.T, username string, d time.Duration) {
+	var audits []model.LoginAudit
+	require.NoError(t, testDB.Where("username = ?", username).Find(&audits).Error)
+	for _, audit := range audits {
+		require.NoError(t, testDB.Model(&audit).UpdateColumn("created_at", audit.CreatedAt.Add(-d)).Error)
+	}
+}
+
+// --- Login Protection Tests ---
+
+func TestLoginUniformError(t *testing.T) {
+	enableAuth(t)
+	createTestUser(t, "uniform-user", model.UserRoleAdmin, 0)
+
+	wrongPassword := login(t, "uniform-user", "wrong-password")
+	unknownUser := login(t, "uniform-nobody", "wrong-password")
+	assert.Equal(t, handler.FailCode, wrongPassword.Code)
+	assert.Equal(t, handler.FailCode, unknownUser.Code)
+	assert.Equal(t, wrongPassword.Msg, unknownUser.Msg)
+}
+
+func TestLoginThrottleByUsername(t *testing.T) {
+	enableAuth(t)
+	cfg := config.GetConfig()
+	cfg.Auth.LoginMaxAttempts = 3
+	cfg.Auth.LoginMaxAttemptsPerIP = 0
+	cfg.Auth.LoginLockout = time.Minute
+	createTestUser(t, "throttled-user", model.UserRoleAdmin, 0)
+
+	// 1. The account locks after repeated failures, even for the right password
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, handler.FailCode, login(t, "throttled-user", "wrong-password").Code)
+	}
+	locked := login(t, "throttled-user", "password")
+	assert.Equal(t, handler.FailCode, locked.Code)
+	assert.Contains(t, locked.Msg, "尝试次数过多")
+
+	var count int64
+	require.NoError(t, testDB.Model(&model.LoginAudit{}).
+		Where("username = ? AND result = ?", "throttled-user", model.LoginResultLocked).
+		Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 2. The lock expires and a successful login resets the counter
+	ageLoginAudits(t, "throttled-user", 2*time.Minute)
+	assert.Equal(t, handler.SuccessCode, login(t, "throttled-user", "password").Code)
+	assert.Equal(t, handler.FailCode, login(t, "throttled-user", "wrong-password").Code)
+	assert.Equal(t, handler.SuccessCode, login(t, "throttled-user", "password").Code)
+
+	// 3. Every further failure after the lock expires doubles the lockout
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, handler.FailCode, login(t, "throttled-user", "wrong-password").Code)
+	}
+	ageLoginAudits(t, "throttled-user", 2*time.Minute)
+	assert.Equal(t, handler.FailCode, login(t, "throttled-user", "wrong-password").Code)
+	ageLoginAudits(t, "throttled-user", 90*time.Second)
+	assert.Equal(t, handler.FailCode, login(t, "throttled-user", "password").Code)
+	ageLoginAudits(t, "throttled-user", time.Minute)
+	assert.Equal(t, handler.SuccessCode, login(t, "throttled-user", "password").Code)
+}
+
+func TestLoginThrottleByIP(t *testing.T) {
+	enableAuth(t)
+	cfg := config.GetConfig()
+	createTestUser(t, "ip-user", model.UserRoleAdmin, 0)
+	cfg.Auth.LoginMaxAttempts = 0
+	cfg.Auth.LoginMaxAttemptsPerIP = 2
+	cfg.Auth.LoginLockout = time.Minute
+
+	// 1. Logging in to one's own account does not clear failures for other usernames
+	assert.Equal(t, handler.FailCode, loginFrom(t, "ip-guess-1", "password", "203.0.113.7").Code)
+	assert.Equal(t, handler.SuccessCode, loginFrom(t, "ip-user", "password", "203.0.113.7").Code)
+	assert.Equal(t, handler.FailCode, loginFrom(t, "ip-guess-2", "password", "203.0.113.7").Code)
+
+	assert.Equal(t, handler.FailCode, loginFrom(t, "ip-user", "password", "203.0.113.7").Code)
+	assert.Equal(t, handler.SuccessCode, loginFrom(t, "ip-user", "password", "198.51.100.3").Code)
+
+	// 2. A successful login clears earlier failures for the same username from that IP
+	assert.Equal(t, handler.FailCode, loginFrom(t, "ip-user", "wrong-password", "198.51.100.3").Code)
+	assert.Equal(t, handler.SuccessCode, loginFrom(t, "ip-user", "password", "198.51.100.3").Code)
+	assert.Equal(t, handler.FailCode, loginFrom(t, "ip-user", "wrong-password", "198.51.100.3").Code)
+	assert.Equal(t, handler.SuccessCode, loginFrom(t, "ip-user", "password", "198.51.100.3").Code)
+}
+
+func TestLoginAuditLog(t *testing.T) {
+	enableAuth(t)
+	_, adminToken := createTestUser(t, "audit-admin", model.UserRoleAdmin, 0)
+	_, editorToken := createTestUser(t, "audit-editor", model.UserRoleEditor, 0)
+
+	login(t, "audit-editor", "wrong-password")
+
+	w := performAuthRequest("GET", "/user/audit?username=audit-editor&page=1&page_size=10", nil, adminToken)
+	var resp loginAuditResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code)
+	require.Equal(t, int64(2), resp.Data.Total)
+	assert.Equal(t, model.LoginResultFailure, resp.Data.List[0].Result)
+	assert.Equal(t, model.LoginResultSuccess, resp.Data.List[1].Result)
+	assert.Equal(t, "192.0.2.1", resp.Data.List[0].IP)
+
+	w = performAuthRequest("GET", "/user/audit?result=failure&username=audit-editor&page=1&page_size=10", nil, adminToken)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1), resp.Data.Total)
+
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/audit?page=1&page_size=10", nil, editorToken))
+}
@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Tag and Collection Match Tests ---
+
+func TestTagCollectionMatch(t *testing.T) {
+	category := createTestCategory(t, "Match Shelf")
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Match Favorite"}, ""))
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Match Reread"}, ""))
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/collection", map[string]string{"name": "Match Lent Out"}, ""))
+	var favorite, reread model.Tag
+	require.NoError(t, testDB.Where("name = ?", "Match Favorite").First(&favorite).Error)
+	require.NoError(t, testDB.Where("name = ?", "Match Reread").First(&reread).Error)
+	var lent model.Collection
+	require.NoError(t, testDB.Where("name = ?", "Match Lent Out").First(&lent).Error)
+
+	createItem := func(name string, tags []uint, lentOut bool) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo},
+		}, ""))
+		var item model.Item
+		require.NoError(t, testDB.Where("name = ?", name).First(&item).Error)
+		for _, tagID := range tags {
+			require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, tagID), nil, ""))
+		}
+		if lentOut {
+			require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/collection/%d", item.ID, lent.ID), nil, ""))
+		}
+	}
+	createItem("Match 1", []uint{favorite.ID, reread.ID}, false)
+	createItem("Match 2", []uint{favorite.ID}, true)
+	createItem("Match 3", []uint{favorite.ID, reread.ID}, true)
+	createItem("Match 4", nil, false)
+
+	both := []uint{favorite.ID, reread.ID}
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want []string
+	}{
+		{"any by default", map[string]interface{}{"tag_ids": both}, []string{"Match 1", "Match 2", "Match 3"}},
+		{"any", map[string]interface{}{"tag_ids": both, "tag_match": "any"}, []string{"Match 1", "Match 2", "Match 3"}},
+		{"all", map[string]interface{}{"tag_ids": both, "tag_match": "all"}, []string{"Match 1", "Match 3"}},
+		{"all counts duplicates once", map[string]interface{}{"tag_ids": []uint{favorite.ID, favorite.ID}, "tag_match": "all"}, []string{"Match 1", "Match 2", "Match 3"}},
+		{"none", map[string]interface{}{"tag_ids": both, "tag_match": "none"}, []string{"Match 4"}},
+		{"exclude tags", map[string]interface{}{"exclude_tag_ids": []uint{reread.ID}}, []string{"Match 2", "Match 4"}},
+		{"collection all", map[string]interface{}{"collection_ids": []uint{lent.ID}, "collection_match": "all"}, []string{"Match 2", "Match 3"}},
+		{"collection none", map[string]interface{}{"collection_ids": []uint{lent.ID}, "collection_match": "none"}, []string{"Match 1", "Match 4"}},
+		{"all tags but not lent out", map[string]interface{}{"tag_ids": both, "tag_match": "all", "exclude_collection_ids": []uint{lent.ID}}, []string{"Match 1"}},
+	}
+	for _, tt := range tests {
+		tt.body["category_id"] = category.ID
+		tt.body["sort"] = "name"
+		assert.Equal(t, tt.want, searchNames(t, tt.body), tt.name)
+	}
+
+	code, _ := searchItemsTotal(t, map[string]interface{}{"tag_ids": both, "tag_match": "most"})
+	assert.Equal(t, handler.FailCode, code)
+}
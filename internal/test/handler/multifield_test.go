@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Multi-Field Filter Tests ---
+
+func TestMultiFieldFilters(t *testing.T) {
+	category := createTestCategory(t, "And Shelf")
+	authors := createTestField(t, category.ID, map[string]interface{}{"name": "Authors", "type": model.FieldTypeString, "is_array": true})
+	year := createTestField(t, category.ID, map[string]interface{}{"name": "Year", "type": model.FieldTypeInt})
+	format := createTestField(t, category.ID, map[string]interface{}{
+		"name":    "Format",
+		"type":    model.FieldTypeSelect,
+		"options": []map[string]string{{"value": "Hardcover"}, {"value": "Paperback"}},
+	})
+
+	createItem := func(name string, names []string, published int, formatName string) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item": map[string]interface{}{
+				"name":   name,
+				"status": model.ItemStatusTodo,
+				"values": []map[string]interface{}{
+					{"field_id": authors.ID, "value": names},
+					{"field_id": year.ID, "value": published},
+					{"field_id": format.ID, "value": formatName},
+				},
+			},
+		}, ""))
+	}
+	createItem("And American Gods", []string{"Neil Gaiman"}, 2001, "Hardcover")
+	createItem("And Good Omens", []string{"Neil Gaiman", "Terry Pratchett"}, 1990, "Paperback")
+	createItem("And Coraline", []string{"Neil Gaiman"}, 2002, "Paperback")
+	createItem("And Going Postal", []string{"Terry Pratchett"}, 2004, "Hardcover")
+	createItem("And Nation", []string{"Terry Pratchett"}, 2008, "Paperback")
+
+	filters := func(conditions map[uint]interface{}) []string {
+		body := map[string]interface{}{"category_id": category.ID, "sort": "name", "filters": conditions}
+		return searchNames(t, body)
+	}
+	op := func(op string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"op": op, "value": value}
+	}
+
+	tests := []struct {
+		name       string
+		conditions map[uint]interface{}
+		want       []string
+	}{
+		{"two fields", map[uint]interface{}{authors.ID: "Gaiman", year.ID: 2001}, []string{"And American Gods"}},
+		{"three fields", map[uint]interface{}{authors.ID: "Pratchett", year.ID: op("gte", 2000), format.ID: "Paperback"}, []string{"And Nation"}},
+		{"operators on two fields", map[uint]interface{}{authors.ID: op("contains", "gaiman"), year.ID: op("between", []int{1990, 2001})}, []string{"And American Gods", "And Good Omens"}},
+		{"negated field with another field", map[uint]interface{}{authors.ID: op("ne", "Neil Gaiman"), format.ID: "Hardcover"}, []string{"And Going Postal"}},
+		{"no match across fields", map[uint]interface{}{authors.ID: "Gaiman", year.ID: 2004}, nil},
+		{"same array field, each condition matches any value", map[uint]interface{}{authors.ID: []interface{}{op("contains", "Gaiman"), op("contains", "Pratchett")}}, []string{"And Good Omens"}},
+		{"same field range", map[uint]interface{}{year.ID: []interface{}{op("gt", 2001), op("lt", 2008)}, format.ID: op("in", []string{"Hardcover", "Paperback"})}, []string{"And Coraline", "And Going Postal"}},
+		{"plain array stays one condition", map[uint]interface{}{authors.ID: []string{"Gaiman", "Pratchett"}, year.ID: op("lt", 2002)}, []string{"And American Gods", "And Good Omens"}},
+	}
+	for _, tt := range tests {
+		names := filters(tt.conditions)
+		if tt.want == nil {
+			assert.Empty(t, names, tt.name)
+		} else {
+			assert.Equal(t, tt.want, names, tt.name)
+		}
+	}
+
+	// Field filters, query terms and item attributes combine independently
+	names := searchNames(t, map[string]interface{}{
+		"category_id": category.ID,
+		"sort":        "name",
+		"filters":     map[uint]interface{}{format.ID: "Paperback"},
+		"q":           fmt.Sprintf(`authors:gaiman authors:pratchett OR year>=%d`, 2008),
+	})
+	assert.Equal(t, []string{"And Good Omens", "And Nation"}, names)
+}
@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"collectify/internal/service"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchResponse struct {
+	CommonResponse
+	Data struct {
+		List []struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	} `json:"data"`
+}
+
+// createTestUser creates a user that can log in right away and returns its token.
+func createTestUser(t *testing.T, username string, role int, libraryID uint) (model.User, string) {
+	user, err := service.CreateUser(username, "password", role, libraryID)
+	require.NoError(t, err)
+	require.NoError(t, testDB.Model(&user).Update("must_change_password", false).Error)
+
+	resp := login(t, username, "password")
+	require.Equal(t, handler.SuccessCode, resp.Code)
+	return user, resp.Data.Token
+}
+
+func requestCode(t *testing.T, method, target string, body interface{}, token string) int {
+	w := performAuthRequest(method, target, body, token)
+	var resp CommonResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Code
+}
+
+// --- Multi-user Tests ---
+
+func TestUserManagement(t *testing.T) {
+	enableAuth(t)
+	_, adminToken := createTestUser(t, "manager", model.UserRoleAdmin, 0)
+
+	// 1. Admins create users who must change their password first
+	w := performAuthRequest("POST", "/user", map[string]interface{}{
+		"username": "newcomer",
+		"password": "initial-password",
+		"role":     model.UserRoleAdmin,
+	}, adminToken)
+	var resp CommonResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	var newcomer model.User
+	require.NoError(t, testDB.Where("username = ?", "newcomer").First(&newcomer).Error)
+	assert.True(t, newcomer.MustChangePassword)
+
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user", map[string]interface{}{
+		"username": "newcomer",
+		"password": "initial-password",
+		"role":     model.UserRoleAdmin,
+	}, adminToken))
+
+	// 2. Disabled users can neither use their token nor log in again
+	_, token := createTestUser(t, "leaver", model.UserRoleAdmin, 0)
+	var leaver model.User
+	require.NoError(t, testDB.Where("username = ?", "leaver").First(&leaver).Error)
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, token))
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/disable", leaver.ID), nil, adminToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/category/list", nil, token))
+	assert.Equal(t, handler.FailCode, login(t, "leaver", "password").Code)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/enable", leaver.ID), nil, adminToken))
+	assert.Equal(t, handler.SuccessCode, login(t, "leaver", "password").Code)
+
+	// 3. Users listing requires a token
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/list", nil, ""))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/list", nil, adminToken))
+}
+
+func TestUserLibraryIsolation(t *testing.T) {
+	enableAuth(t)
+	alice, aliceToken := createTestUser(t, "alice", model.UserRoleAdmin, 0)
+	_, bobToken := createTestUser(t, "bob", model.UserRoleAdmin, 0)
+
+	// Prerequisite: each user owns a category of the same name, alice owns an item and a tag
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": "Shelf"}, aliceToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": "Shelf"}, bobToken))
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ? AND user_id = ?", "Shelf", alice.ID).First(&category).Error)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Alice's Book", "status": model.ItemStatusTodo},
+	}, aliceToken))
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "Alice's Book").First(&item).Error)
+	assert.Equal(t, alice.ID, item.UserID)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Alice's Tag"}, aliceToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Bob's Tag"}, bobToken))
+	var bobTag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "Bob's Tag").First(&bobTag).Error)
+
+	// 1. Bob cannot read alice's item, category or tags
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", item.ID), nil, bobToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", item.ID), nil, aliceToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", fmt.Sprintf("/category/%d", category.ID), nil, bobToken))
+
+	w := performAuthRequest("POST", "/item/search", map[string]interface{}{"name": "Alice"}, bobToken)
+	var search searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &search))
+	assert.Equal(t, handler.SuccessCode, search.Code)
+	assert.Equal(t, int64(0), search.Data.Total)
+
+	w = performAuthRequest("POST", "/item/search", map[string]interface{}{"name": "Alice"}, aliceToken)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &search))
+	assert.Equal(t, int64(1), search.Data.Total)
+
+	w = performAuthRequest("GET", "/tag/list", nil, bobToken)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &search))
+	for _, tag := range search.Data.List {
+		assert.NotEqual(t, "Alice's Tag", tag.Name)
+	}
+
+	// 2. Bob cannot mutate alice's item or category
+	assert.Equal(t, handler.FailCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", item.ID), map[string]interface{}{
+		"id":   item.ID,
+		"item": map[string]interface{}{"name": "Stolen", "status": model.ItemStatusTodo},
+	}, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", item.ID), nil, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, bobTag.ID), nil, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "PATCH", fmt.Sprintf("/category/%d", category.ID), map[string]string{"name": "Mine"}, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "DELETE", fmt.Sprintf("/category/%d", category.ID), nil, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", map[string]interface{}{
+		"category_id": category.ID,
+		"name":        "Injected",
+		"type":        model.FieldTypeString,
+	}, bobToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Planted", "status": model.ItemStatusTodo},
+	}, bobToken))
+
+	var stored model.Item
+	require.NoError(t, testDB.First(&stored, item.ID).Error)
+	assert.Equal(t, "Alice's Book", stored.Name)
+	var storedCategory model.Category
+	require.NoError(t, testDB.First(&storedCategory, category.ID).Error)
+	assert.Equal(t, "Shelf", storedCategory.Name)
+
+	// 3. Alice's deleted item is not in bob's recycle bin and bob cannot restore it
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", item.ID), nil, aliceToken))
+	w = performAuthRequest("GET", "/deleted?type=item", nil, bobToken)
+	var deleted deletedListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deleted))
+	assert.Equal(t, int64(0), deleted.Data.Total)
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/deleted/restore", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": item.ID, "type": model.ModelTypeItem}},
+	}, bobToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/deleted/restore", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": item.ID, "type": model.ModelTypeItem}},
+	}, aliceToken))
+}
@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"collectify/internal/dao"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listNames 按名称搜索分类、标签或收藏夹列表，返回名称
+func listNames(t *testing.T, resource, name string) []string {
+	w := performRequest("GET", fmt.Sprintf("/%s/list?name=%s", resource, url.QueryEscape(name)), nil)
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code, resp.Msg)
+
+	names := make([]string, len(resp.Data.List))
+	for i, item := range resp.Data.List {
+		names[i] = item.Name
+	}
+	return names
+}
+
+// --- Normalized Name Search Tests ---
+
+func TestNameKeySearch(t *testing.T) {
+	category := createTestCategory(t, "Nörmalized Shelf")
+	createItem := func(name string, aliases ...string) {
+		var aliasList []map[string]interface{}
+		for _, alias := range aliases {
+			aliasList = append(aliasList, map[string]interface{}{"name": alias})
+		}
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo, "aliases": aliasList},
+		}, ""))
+	}
+	createItem("Tólkien Letters")
+	createItem("三体")
+	createItem("The Three-Body Problem", "地球往事")
+	createItem("Ｆｕｌｌ Width 100%")
+
+	// 1. Item names and aliases match regardless of case, diacritics and width, and by pinyin
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"tolkien", []string{"Tólkien Letters"}},
+		{"TÓLKIEN", []string{"Tólkien Letters"}},
+		{"santi", []string{"三体"}},
+		{"SanTi", []string{"三体"}},
+		{"st", []string{"三体"}},
+		{"三体", []string{"三体"}},
+		{"diqiu", []string{"The Three-Body Problem"}},
+		{"dqws", []string{"The Three-Body Problem"}},
+		{"full width", []string{"Ｆｕｌｌ Width 100%"}},
+		{"0%", []string{"Ｆｕｌｌ Width 100%"}},
+		{"_", nil},
+	}
+	for _, tt := range tests {
+		names := searchNames(t, map[string]interface{}{"category_id": category.ID, "name": tt.name, "sort": "name"})
+		if tt.want == nil {
+			assert.Empty(t, names, tt.name)
+		} else {
+			assert.Equal(t, tt.want, names, tt.name)
+		}
+	}
+	assert.Equal(t, []string{"三体"}, searchNames(t, map[string]interface{}{"category_id": category.ID, "q": "santi"}))
+	assert.Equal(t, []string{"Tólkien Letters"}, searchNames(t, map[string]interface{}{"category_id": category.ID, "q": "name:tolkien"}))
+
+	// 2. Renaming an item updates its key
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "三体").First(&item).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", item.ID), map[string]interface{}{
+		"id":   item.ID,
+		"item": map[string]interface{}{"name": "球状闪电", "status": model.ItemStatusTodo},
+	}, ""))
+	assert.Empty(t, searchNames(t, map[string]interface{}{"category_id": category.ID, "name": "santi"}))
+	assert.Equal(t, []string{"球状闪电"}, searchNames(t, map[string]interface{}{"category_id": category.ID, "name": "qiuzhuang"}))
+
+	// 3. Category, tag and collection lists filter by the same keys
+	assert.Equal(t, []string{"Nörmalized Shelf"}, listNames(t, "category", "normalized"))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "硬科幻"}, ""))
+	assert.Equal(t, []string{"硬科幻"}, listNames(t, "tag", "kehuan"))
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "硬科幻").First(&tag).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PATCH", fmt.Sprintf("/tag/%d", tag.ID), map[string]string{"name": "软科幻"}, ""))
+	assert.Empty(t, listNames(t, "tag", "yingkehuan"))
+	assert.Equal(t, []string{"软科幻"}, listNames(t, "tag", "rkh"))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/collection", map[string]string{"name": "Café Reads"}, ""))
+	assert.Equal(t, []string{"Café Reads"}, listNames(t, "collection", "cafe"))
+
+	// 4. Records without a key, such as those created before upgrading, get one on startup
+	require.NoError(t, testDB.Exec("UPDATE tags SET name_key = '' WHERE id = ?", tag.ID).Error)
+	assert.Empty(t, listNames(t, "tag", "rkh"))
+	require.NoError(t, dao.InitNameKeys(testDB))
+	assert.Equal(t, []string{"软科幻"}, listNames(t, "tag", "rkh"))
+}
@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Float Field Tests ---
+
+func TestFloatField(t *testing.T) {
+	category := createTestCategory(t, "Float Shelf")
+
+	// 1. Only float fields have a unit and precision
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", map[string]interface{}{
+		"category_id": category.ID,
+		"name":        "Text Unit",
+		"type":        model.FieldTypeString,
+		"unit":        "kg",
+	}, ""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", map[string]interface{}{
+		"category_id": category.ID,
+		"name":        "Too Precise",
+		"type":        model.FieldTypeFloat,
+		"precision":   11,
+	}, ""))
+
+	price := createTestField(t, category.ID, map[string]interface{}{
+		"name":      "Price",
+		"type":      model.FieldTypeFloat,
+		"unit":      "USD",
+		"precision": 2,
+	})
+	weights := createTestField(t, category.ID, map[string]interface{}{
+		"name":     "Weights",
+		"type":     model.FieldTypeFloat,
+		"is_array": true,
+		"unit":     "kg",
+	})
+
+	w := performRequest("GET", fmt.Sprintf("/category/%d", category.ID), nil)
+	var categoryResp struct {
+		CommonResponse
+		Data struct {
+			Fields []struct {
+				Unit      string `json:"unit"`
+				Precision *int   `json:"precision"`
+			} `json:"fields"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &categoryResp))
+	require.Len(t, categoryResp.Data.Fields, 2)
+	assert.Equal(t, "USD", categoryResp.Data.Fields[0].Unit)
+	require.NotNil(t, categoryResp.Data.Fields[0].Precision)
+	assert.Equal(t, 2, *categoryResp.Data.Fields[0].Precision)
+	assert.Nil(t, categoryResp.Data.Fields[1].Precision)
+
+	// 2. Values keep their fraction and are rounded to the precision
+	createItem := func(name string, values []map[string]interface{}) int {
+		return requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo, "values": values},
+		}, "")
+	}
+	assert.Equal(t, handler.SuccessCode, createItem("Float Cheap", []map[string]interface{}{
+		{"field_id": price.ID, "value": 9.999},
+		{"field_id": weights.ID, "value": []float64{0.25, 1.5}},
+	}))
+	assert.Equal(t, handler.SuccessCode, createItem("Float Pricey", []map[string]interface{}{
+		{"field_id": price.ID, "value": "129.5"},
+	}))
+	assert.Equal(t, handler.FailCode, createItem("Float Invalid", []map[string]interface{}{
+		{"field_id": price.ID, "value": "cheap"},
+	}))
+
+	var cheap model.Item
+	require.NoError(t, testDB.Where("name = ?", "Float Cheap").First(&cheap).Error)
+	values := getItemValues(t, cheap.ID)
+	assert.Equal(t, 10.0, values[price.ID])
+	assert.ElementsMatch(t, []interface{}{0.25, 1.5}, values[weights.ID])
+
+	// 3. Filters accept ranges and exact values
+	code, total := searchTotal(t, category.ID, map[uint]interface{}{price.ID: map[string]interface{}{"min": 5, "max": 100}})
+	require.Equal(t, handler.SuccessCode, code)
+	assert.Equal(t, int64(1), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{price.ID: map[string]interface{}{"min": 10}})
+	assert.Equal(t, int64(2), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{price.ID: map[string]interface{}{"max": 9.99}})
+	assert.Equal(t, int64(0), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{price.ID: 129.5})
+	assert.Equal(t, int64(1), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{weights.ID: map[string]interface{}{"min": 1, "max": 2}})
+	assert.Equal(t, int64(1), total)
+
+	code, _ = searchTotal(t, category.ID, map[uint]interface{}{price.ID: map[string]interface{}{}})
+	assert.Equal(t, handler.FailCode, code)
+}
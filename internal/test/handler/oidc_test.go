@@ -0,0 +1,281 @@
+package handler_test
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mockOIDCClientID     = "collectify"
+	mockOIDCClientSecret = "collectify-secret"
+	mockOIDCKeyID        = "mock-key"
+)
+
+type oidcLoginResponse struct {
+	CommonResponse
+	Data struct {
+		URL   string `json:"url"`
+		State string `json:"state"`
+	} `json:"data"`
+}
+
+// mockOIDCIssuer is an in-process OpenID Connect provider that issues
+// authorization codes for the claims registered by a test.
+type mockOIDCIssuer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	next  int
+	codes map[string]jwt.MapClaims
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockOIDCIssuer{codes: map[string]jwt.MapClaims{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": mockOIDCKeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != mockOIDCClientID || clientSecret != mockOIDCClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(w, map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		m.mu.Lock()
+		claims, ok := m.codes[r.FormValue("code")]
+		delete(m.codes, r.FormValue("code"))
+		m.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = mockOIDCKeyID
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// issueCode registers an authorization code for the given subject and extra claims.
+func (m *mockOIDCIssuer) issueCode(subject, nonce string, extra jwt.MapClaims) string {
+	claims := jwt.MapClaims{
+		"iss":   m.URL,
+		"sub":   subject,
+		"aud":   mockOIDCClientID,
+		"nonce": nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next++
+	code := fmt.Sprintf("code-%d", m.next)
+	m.codes[code] = claims
+	return code
+}
+
+// enableOIDC points the OIDC config at the mock issuer for the duration of a test.
+func enableOIDC(t *testing.T, m *mockOIDCIssuer) {
+	enableAuth(t)
+	cfg := config.GetConfig()
+	cfg.Auth.OIDCIssuer = m.URL
+	cfg.Auth.OIDCClientID = mockOIDCClientID
+	cfg.Auth.OIDCClientSecret = mockOIDCClientSecret
+	cfg.Auth.OIDCRedirectURL = "http://localhost:3000/oidc/callback"
+}
+
+// startOIDCLogin returns the state and nonce of a new OIDC login.
+func startOIDCLogin(t *testing.T) (string, string) {
+	w := performRequest("GET", "/user/oidc/login", nil)
+	var resp oidcLoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code)
+
+	authURL, err := url.Parse(resp.Data.URL)
+	require.NoError(t, err)
+	assert.Equal(t, mockOIDCClientID, authURL.Query().Get("client_id"))
+	assert.Equal(t, resp.Data.State, authURL.Query().Get("state"))
+	return resp.Data.State, authURL.Query().Get("nonce")
+}
+
+// oidcLogin completes an OIDC login as the given subject.
+func oidcLogin(t *testing.T, m *mockOIDCIssuer, subject string, extra jwt.MapClaims) loginResponse {
+	state, nonce := startOIDCLogin(t)
+	return oidcCallback(t, m.issueCode(subject, nonce, extra), state)
+}
+
+func oidcCallback(t *testing.T, code, state string) loginResponse {
+	w := performRequest("POST", "/user/oidc/callback", map[string]string{
+		"code":  code,
+		"state": state,
+	})
+	var resp loginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// --- OIDC Tests ---
+
+func TestOIDCLogin(t *testing.T) {
+	m := newMockOIDCIssuer(t)
+
+	// 1. OIDC login is off until an issuer is configured
+	enableAuth(t)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/oidc/login", nil, ""))
+	enableOIDC(t, m)
+	w := performRequest("GET", "/user/oidc/enabled", nil)
+	var enabled struct {
+		CommonResponse
+		Data bool `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enabled))
+	assert.True(t, enabled.Data)
+
+	// 2. The first login provisions a user, later logins reuse it
+	first := oidcLogin(t, m, "oidc-alice-sub", jwt.MapClaims{"preferred_username": "oidc-alice"})
+	require.Equal(t, handler.SuccessCode, first.Code, first.Msg)
+	assert.Equal(t, "oidc-alice", first.Data.Username)
+	assert.False(t, first.Data.MustChangePassword)
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, first.Data.Token))
+
+	var user model.User
+	require.NoError(t, testDB.Where("username = ?", "oidc-alice").First(&user).Error)
+	assert.Equal(t, model.UserRoleEditor, user.Role)
+
+	second := oidcLogin(t, m, "oidc-alice-sub", jwt.MapClaims{"preferred_username": "renamed"})
+	require.Equal(t, handler.SuccessCode, second.Code)
+	assert.Equal(t, user.ID, second.Data.ID)
+
+	// 3. Existing local users are never taken over by name
+	local, _ := createTestUser(t, "oidc-local", model.UserRoleAdmin, 0)
+	other := oidcLogin(t, m, "oidc-local-sub", jwt.MapClaims{"preferred_username": "oidc-local"})
+	require.Equal(t, handler.SuccessCode, other.Code)
+	assert.NotEqual(t, local.ID, other.Data.ID)
+	assert.Equal(t, "oidc-local-2", other.Data.Username)
+
+	// 4. Disabled users cannot log in
+	require.NoError(t, testDB.Model(&user).Update("disabled", true).Error)
+	assert.Equal(t, handler.FailCode, oidcLogin(t, m, "oidc-alice-sub", nil).Code)
+}
+
+func TestOIDCLoginRejectsInvalidCallbacks(t *testing.T) {
+	m := newMockOIDCIssuer(t)
+	enableOIDC(t, m)
+
+	// Forged state
+	_, nonce := startOIDCLogin(t)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":   "oidc_state",
+		"nonce": nonce,
+	}).SignedString([]byte("other-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, handler.FailCode, oidcCallback(t, m.issueCode("oidc-forged", nonce, nil), forged).Code)
+
+	// Nonce of another login
+	state, _ := startOIDCLogin(t)
+	assert.Equal(t, handler.FailCode, oidcCallback(t, m.issueCode("oidc-replayed", "other-nonce", nil), state).Code)
+
+	// Unknown code
+	state, _ = startOIDCLogin(t)
+	assert.Equal(t, handler.FailCode, oidcCallback(t, "unknown-code", state).Code)
+
+	// The state cannot be used as an access token
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, state))
+
+	var count int64
+	require.NoError(t, testDB.Model(&model.User{}).
+		Where("username IN ?", []string{"oidc-forged", "oidc-replayed"}).
+		Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestOIDCAllowedGroups(t *testing.T) {
+	m := newMockOIDCIssuer(t)
+	enableOIDC(t, m)
+	cfg := config.GetConfig()
+	cfg.Auth.OIDCAllowedGroups = []string{"collectify-users"}
+	cfg.Auth.OIDCDefaultRole = model.UserRoleViewer
+
+	denied := oidcLogin(t, m, "oidc-outsider", jwt.MapClaims{"groups": []string{"staff"}})
+	assert.Equal(t, handler.FailCode, denied.Code)
+	assert.Equal(t, handler.FailCode, oidcLogin(t, m, "oidc-nogroups", nil).Code)
+
+	allowed := oidcLogin(t, m, "oidc-member", jwt.MapClaims{
+		"email":  "member@example.com",
+		"groups": []string{"staff", "collectify-users"},
+	})
+	require.Equal(t, handler.SuccessCode, allowed.Code)
+	assert.Equal(t, "member@example.com", allowed.Data.Username)
+
+	var user model.User
+	require.NoError(t, testDB.First(&user, allowed.Data.ID).Error)
+	assert.Equal(t, model.UserRoleViewer, user.Role)
+
+	var audit model.LoginAudit
+	require.NoError(t, testDB.Where("username = ?", "oidc-outsider").Last(&audit).Error)
+	assert.Equal(t, model.LoginResultFailure, audit.Result)
+}
@@ -0,0 +1,104 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Field Filter Operator Tests ---
+
+func TestFieldFilterOperators(t *testing.T) {
+	category := createTestCategory(t, "Operator Shelf")
+
+	author := createTestField(t, category.ID, map[string]interface{}{"name": "Author", "type": model.FieldTypeString})
+	year := createTestField(t, category.ID, map[string]interface{}{"name": "Year", "type": model.FieldTypeInt})
+	checks := createTestField(t, category.ID, map[string]interface{}{"name": "Checks", "type": model.FieldTypeBool, "is_array": true})
+	reads := createTestField(t, category.ID, map[string]interface{}{"name": "Reads", "type": model.FieldTypeDatetime, "is_array": true})
+	format := createTestField(t, category.ID, map[string]interface{}{
+		"name":    "Format",
+		"type":    model.FieldTypeSelect,
+		"options": []map[string]string{{"value": "hardcover"}, {"value": "ebook"}},
+	})
+
+	createItem := func(name string, values []map[string]interface{}) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo, "values": values},
+		}, ""))
+	}
+	createItem("Operator Dispossessed", []map[string]interface{}{
+		{"field_id": author.ID, "value": "Ursula K. Le Guin"},
+		{"field_id": year.ID, "value": 1974},
+		{"field_id": checks.ID, "value": []bool{true, false}},
+		{"field_id": reads.ID, "value": []string{"2019-03-01T00:00:00Z", "2023-07-15T12:00:00Z"}},
+		{"field_id": format.ID, "value": "hardcover"},
+	})
+	createItem("Operator Earthsea", []map[string]interface{}{
+		{"field_id": author.ID, "value": "Ursula K. Le Guin"},
+		{"field_id": year.ID, "value": 1968},
+		{"field_id": checks.ID, "value": []bool{true}},
+		{"field_id": format.ID, "value": "ebook"},
+	})
+	createItem("Operator Neuromancer", []map[string]interface{}{
+		{"field_id": author.ID, "value": "William Gibson 100%"},
+		{"field_id": year.ID, "value": 1984},
+	})
+
+	tests := []struct {
+		name    string
+		fieldID uint
+		filter  map[string]interface{}
+		want    int64
+	}{
+		{"string eq is exact", author.ID, map[string]interface{}{"op": "eq", "value": "Ursula K. Le Guin"}, 2},
+		{"string eq partial", author.ID, map[string]interface{}{"op": "eq", "value": "Le Guin"}, 0},
+		{"string contains", author.ID, map[string]interface{}{"op": "contains", "value": "Gibson"}, 1},
+		{"string contains escapes wildcards", author.ID, map[string]interface{}{"op": "contains", "value": "100%"}, 1},
+		{"string contains literal percent", author.ID, map[string]interface{}{"op": "contains", "value": "%"}, 1},
+		{"string starts_with", author.ID, map[string]interface{}{"op": "starts_with", "value": "Ursula"}, 2},
+		{"string ne", author.ID, map[string]interface{}{"op": "ne", "value": "Ursula K. Le Guin"}, 1},
+		{"int gt", year.ID, map[string]interface{}{"op": "gt", "value": 1970}, 2},
+		{"int lte", year.ID, map[string]interface{}{"op": "lte", "value": 1974}, 2},
+		{"int between", year.ID, map[string]interface{}{"op": "between", "value": []int{1970, 1980}}, 1},
+		{"int range", year.ID, map[string]interface{}{"min": 1970, "max": 1980}, 1},
+		{"int range min only", year.ID, map[string]interface{}{"min": 1974}, 2},
+		{"int range max only", year.ID, map[string]interface{}{"max": 1974}, 2},
+		{"int in", year.ID, map[string]interface{}{"op": "in", "value": []int{1968, 1984, 2000}}, 2},
+		{"bool array eq", checks.ID, map[string]interface{}{"op": "eq", "value": false}, 1},
+		{"bool array ne", checks.ID, map[string]interface{}{"op": "ne", "value": false}, 2},
+		{"bool array is_empty", checks.ID, map[string]interface{}{"op": "is_empty"}, 1},
+		{"bool array not empty", checks.ID, map[string]interface{}{"op": "is_empty", "value": false}, 2},
+		{"datetime array gte", reads.ID, map[string]interface{}{"op": "gte", "value": "2023-01-01T00:00:00Z"}, 1},
+		{"datetime array between", reads.ID, map[string]interface{}{"op": "between", "value": []string{"2019-01-01T00:00:00Z", "2019-12-31T00:00:00Z"}}, 1},
+		{"datetime array lt", reads.ID, map[string]interface{}{"op": "lt", "value": "2019-01-01T00:00:00Z"}, 0},
+		{"select in", format.ID, map[string]interface{}{"op": "in", "value": []string{"hardcover", "ebook"}}, 2},
+		{"select is_empty", format.ID, map[string]interface{}{"op": "is_empty", "value": true}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, total := searchTotal(t, category.ID, map[uint]interface{}{tt.fieldID: tt.filter})
+			require.Equal(t, handler.SuccessCode, code)
+			assert.Equal(t, tt.want, total)
+		})
+	}
+
+	// Invalid operators and operands are rejected
+	for _, invalid := range []struct {
+		fieldID uint
+		filter  map[string]interface{}
+	}{
+		{author.ID, map[string]interface{}{"op": "like", "value": "x"}},
+		{year.ID, map[string]interface{}{"op": "contains", "value": 19}},
+		{year.ID, map[string]interface{}{"op": "gt", "value": "soon"}},
+		{year.ID, map[string]interface{}{"op": "between", "value": []int{1970}}},
+		{format.ID, map[string]interface{}{"op": "gt", "value": "ebook"}},
+		{checks.ID, map[string]interface{}{"op": "in", "value": []bool{}}},
+	} {
+		code, _ := searchTotal(t, category.ID, map[uint]interface{}{invalid.fieldID: invalid.filter})
+		assert.Equal(t, handler.FailCode, code, invalid.filter)
+	}
+}
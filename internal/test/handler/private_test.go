@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Private Item Tests ---
+
+func TestPrivateItems(t *testing.T) {
+	enableAuth(t)
+	owner, token := createTestUser(t, "private-owner", model.UserRoleAdmin, 0)
+	config.GetConfig().Auth.PublicLibrary = "private-owner"
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": "Diary Shelf"}, token))
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ? AND user_id = ?", "Diary Shelf", owner.ID).First(&category).Error)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Secret Diary", "status": model.ItemStatusTodo, "private": true},
+	}, token))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Open Diary", "status": model.ItemStatusTodo},
+	}, token))
+	var secret, open model.Item
+	require.NoError(t, testDB.Where("name = ?", "Secret Diary").First(&secret).Error)
+	require.NoError(t, testDB.Where("name = ?", "Open Diary").First(&open).Error)
+	assert.True(t, secret.Private)
+
+	searchTotal := func(token string) int64 {
+		w := performAuthRequest("POST", "/item/search", map[string]interface{}{
+			"category_id": category.ID,
+			"name":        "Diary",
+		}, token)
+		var resp searchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, handler.SuccessCode, resp.Code)
+		return resp.Data.Total
+	}
+
+	// 1. Anonymous readers do not see private items in a public instance
+	assert.Equal(t, int64(1), searchTotal(""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", secret.ID), nil, ""))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", open.ID), nil, ""))
+
+	w := performAuthRequest("GET", "/item/list?page=1&page_size=1000", nil, "")
+	var list searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	for _, item := range list.Data.List {
+		assert.NotEqual(t, secret.ID, item.ID)
+	}
+
+	// 2. Logged-in readers still see them
+	assert.Equal(t, int64(2), searchTotal(token))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", secret.ID), nil, token))
+
+	// 3. Updating without the flag makes the item public again
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", secret.ID), map[string]interface{}{
+		"id":   secret.ID,
+		"item": map[string]interface{}{"name": "Secret Diary", "status": model.ItemStatusTodo},
+	}, token))
+	assert.Equal(t, int64(2), searchTotal(""))
+
+	// 4. Anonymous readers only see the public library, not other users' libraries
+	config.GetConfig().Auth.PublicLibrary = ""
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/item/search", map[string]interface{}{"category_id": category.ID}, ""))
+	w = performAuthRequest("POST", "/item/search", map[string]interface{}{"name": "Diary"}, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, int64(0), list.Data.Total)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", fmt.Sprintf("/category/%d", category.ID), nil, ""))
+	w = performAuthRequest("GET", "/category/list?name=Diary", nil, "")
+	var categories searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &categories))
+	assert.Equal(t, handler.SuccessCode, categories.Code)
+	assert.Empty(t, categories.Data.List)
+}
+
+func TestPrivateInstance(t *testing.T) {
+	enableAuth(t)
+	_, token := createTestUser(t, "private-instance", model.UserRoleAdmin, 0)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, ""))
+
+	config.GetConfig().Auth.Private = true
+
+	// Reads require a token in a private instance
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/category/list", nil, ""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/tag/list", nil, ""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/item/list?page=1&page_size=10", nil, ""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/item/search", map[string]interface{}{}, ""))
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, token))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item/search", map[string]interface{}{}, token))
+
+	// Logging in and checking whether auth is enabled stay open
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/enabled", nil, ""))
+	assert.Equal(t, handler.SuccessCode, login(t, "private-instance", "password").Code)
+}
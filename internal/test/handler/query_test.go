@@ -0,0 +1,148 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/query"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Query Language Tests ---
+
+func TestQueryParse(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{`tag:scifi AND rating>=8 AND NOT status:abandoned AND author:"Le Guin"`, `(tag:scifi AND rating>=8 AND NOT status:abandoned AND author:"Le Guin")`},
+		{`a b OR c`, `((a AND b) OR c)`},
+		{`a AND (b OR c)`, `(a AND (b OR c))`},
+		{`-(x OR y) rating>-1`, `(NOT (x OR y) AND rating>"-1")`},
+		{`"Page Count"<=300 sci-fi`, `("Page Count"<=300 AND sci-fi)`},
+		{`"AND" "say \"hi\""`, `("AND" AND "say \"hi\"")`},
+		{`NOT NOT a`, `NOT NOT a`},
+		{`name!=朝花夕拾`, `name!=朝花夕拾`},
+	}
+	for _, tt := range tests {
+		node, err := query.Parse(tt.q)
+		require.NoError(t, err, tt.q)
+		assert.Equal(t, tt.want, node.String(), tt.q)
+
+		// The printed form parses to the same tree
+		again, err := query.Parse(node.String())
+		require.NoError(t, err, node.String())
+		assert.Equal(t, tt.want, again.String(), tt.q)
+	}
+
+	errors := []struct {
+		q   string
+		pos int
+		msg string
+	}{
+		{`rating>=`, 9, `expected value after ">="`},
+		{`(tag:a OR b`, 1, "missing closing parenthesis"},
+		{`author:"Le Guin`, 8, "unterminated string"},
+		{`tag:a OR`, 9, "unexpected end of query"},
+		{`a AND OR b`, 7, `unexpected "OR"`},
+		{`a) b`, 2, `unexpected ")"`},
+		{`朝花 !x`, 4, `unexpected "!"`},
+		{`   `, 1, "empty query"},
+	}
+	for _, tt := range errors {
+		_, err := query.Parse(tt.q)
+		var qerr *query.Error
+		require.ErrorAs(t, err, &qerr, tt.q)
+		assert.Equal(t, tt.pos, qerr.Pos, tt.q)
+		assert.Contains(t, qerr.Msg, tt.msg, tt.q)
+	}
+}
+
+func TestQuerySearch(t *testing.T) {
+	category := createTestCategory(t, "Query Shelf")
+	author := createTestField(t, category.ID, map[string]interface{}{"name": "Author", "type": model.FieldTypeString})
+	pages := createTestField(t, category.ID, map[string]interface{}{"name": "Page Count", "type": model.FieldTypeInt})
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "qscifi"}, ""))
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "qscifi").First(&tag).Error)
+
+	createItem := func(name string, status int, rating interface{}, values []map[string]interface{}, tagged bool) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": status, "rating": rating, "values": values},
+		}, ""))
+		if tagged {
+			var item model.Item
+			require.NoError(t, testDB.Where("name = ?", name).First(&item).Error)
+			require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, tag.ID), nil, ""))
+		}
+	}
+	createItem("Query Dispossessed", model.ItemStatusCompleted, 9, []map[string]interface{}{
+		{"field_id": author.ID, "value": "Ursula K. Le Guin"},
+		{"field_id": pages.ID, "value": 387},
+	}, true)
+	createItem("Query Earthsea", model.ItemStatusAbandoned, 8, []map[string]interface{}{
+		{"field_id": author.ID, "value": "Ursula K. Le Guin"},
+		{"field_id": pages.ID, "value": 183},
+	}, true)
+	createItem("Query Neuromancer", model.ItemStatusTodo, 7, []map[string]interface{}{
+		{"field_id": author.ID, "value": "William Gibson"},
+	}, true)
+	createItem("Query Emma", model.ItemStatusTodo, nil, []map[string]interface{}{
+		{"field_id": author.ID, "value": "Jane Austen"},
+	}, false)
+
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{`tag:qscifi AND rating>=8 AND NOT status:abandoned AND author:"Le Guin"`, []string{"Query Dispossessed"}},
+		{`tag:qscifi -status:abandoned`, []string{"Query Dispossessed", "Query Neuromancer"}},
+		{`author:"Le Guin" OR author:austen`, []string{"Query Dispossessed", "Query Earthsea", "Query Emma"}},
+		{`(status:todo OR status:completed) rating>7`, []string{"Query Dispossessed"}},
+		{`rating!=9`, []string{"Query Earthsea", "Query Emma", "Query Neuromancer"}},
+		{`neuro`, []string{"Query Neuromancer"}},
+		{`"page count">=300 OR author="William Gibson"`, []string{"Query Dispossessed", "Query Neuromancer"}},
+		{`NOT tag:qscifi`, []string{"Query Emma"}},
+		{`tag!=qscifi`, []string{"Query Emma"}},
+		{`author!="Ursula K. Le Guin" status:5`, nil},
+		{`created:today created<="last 7 days"`, []string{"Query Dispossessed", "Query Earthsea", "Query Emma", "Query Neuromancer"}},
+		{`created<yesterday`, nil},
+	}
+	for _, tt := range tests {
+		names := searchNames(t, map[string]interface{}{"category_id": category.ID, "q": tt.q, "sort": "name"})
+		if tt.want == nil {
+			assert.Empty(t, names, tt.q)
+		} else {
+			assert.Equal(t, tt.want, names, tt.q)
+		}
+	}
+
+	// Item attributes work without a category, custom fields need one
+	names := searchNames(t, map[string]interface{}{"q": `tag:qscifi rating<8`})
+	assert.Equal(t, []string{"Query Neuromancer"}, names)
+
+	errors := []struct {
+		body map[string]interface{}
+		msg  string
+	}{
+		{map[string]interface{}{"category_id": category.ID, "q": `rating>=`}, `expected value after ">=" at position 9`},
+		{map[string]interface{}{"category_id": category.ID, "q": `tag:qscifi color:red`}, `unknown field "color" at position 12`},
+		{map[string]interface{}{"q": `author:Gibson`}, "custom fields require category_id"},
+		{map[string]interface{}{"q": `status:lost`}, `invalid status "lost" at position 1`},
+		{map[string]interface{}{"q": `name>x`}, `operator ">" is not supported for name`},
+		{map[string]interface{}{"category_id": category.ID, "q": `"page count">many`}, "at position 1"},
+	}
+	for _, tt := range errors {
+		w := performRequest("POST", "/item/search", tt.body)
+		var resp searchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, handler.FailCode, resp.Code, tt.body["q"])
+		assert.Contains(t, resp.Msg, tt.msg, tt.body["q"])
+	}
+}
@@ -0,0 +1,319 @@
+package handler_test
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"collectify/internal/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deletedListResponse struct {
+	CommonResponse
+	Data struct {
+		List []struct {
+			ID       uint   `json:"id"`
+			Type     string `json:"type"`
+			Name     string `json:"name"`
+			Restores []struct {
+				ID   uint   `json:"id"`
+				Type string `json:"type"`
+			} `json:"restores"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	} `json:"data"`
+}
+
+// --- Recycle Bin Tests ---
+
+func TestDeletedAPI(t *testing.T) {
+	// Prerequisite: a category with a field, an item and a tag.
+	w := performRequest("POST", "/category", map[string]string{"name": "Recycle Bin Category"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ?", "Recycle Bin Category").First(&category).Error)
+
+	w = performRequest("POST", "/field", map[string]interface{}{
+		"category_id": category.ID,
+		"name":        "Publisher",
+		"type":        model.FieldTypeString,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var field model.Field
+	require.NoError(t, testDB.Where("category_id = ? AND name = ?", category.ID, "Publisher").First(&field).Error)
+
+	w = performRequest("POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item": map[string]interface{}{
+			"name":   "Recycled Item",
+			"status": model.ItemStatusTodo,
+			"values": []map[string]interface{}{
+				{"field_id": field.ID, "value": "Some Publisher"},
+			},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "Recycled Item").First(&item).Error)
+
+	w = performRequest("POST", "/tag", map[string]string{"name": "Recycled Tag"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "Recycled Tag").First(&tag).Error)
+	w = performRequest("POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, tag.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 1. Delete the item and find it in the recycle bin
+	w = performRequest("DELETE", fmt.Sprintf("/item/%d", item.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest("GET", "/deleted?type=item", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listResp deletedListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	assert.Equal(t, handler.SuccessCode, listResp.Code)
+	found := false
+	for _, record := range listResp.Data.List {
+		assert.Equal(t, model.ModelTypeItem, record.Type)
+		if record.ID == item.ID {
+			found = true
+			assert.Equal(t, "Recycled Item", record.Name)
+		}
+	}
+	assert.True(t, found, "deleted item not listed in recycle bin")
+
+	// 2. Restore it in batch
+	w = performRequest("POST", "/deleted/restore", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": item.ID, "type": model.ModelTypeItem}},
+	})
+	var resp CommonResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	var count int64
+	testDB.Model(&model.Item{}).Where("id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	testDB.Model(&model.ItemFieldValue{}).Where("item_id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 3. Purging a live record is rejected
+	w = performRequest("POST", "/deleted/purge", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": item.ID, "type": model.ModelTypeItem}},
+	})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	// 4. Delete and purge the tag, its item associations go with it
+	w = performRequest("DELETE", fmt.Sprintf("/tag/%d", tag.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest("POST", "/deleted/purge", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": tag.ID, "type": model.ModelTypeTag}},
+	})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	testDB.Unscoped().Model(&model.Tag{}).Where("id = ?", tag.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Table(model.JoinTableItemTags).Where("tag_id = ?", tag.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 5. A deleted category reports what would be restored alongside it
+	w = performRequest("DELETE", fmt.Sprintf("/category/%d", category.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest("GET", "/deleted?type=category", nil)
+	listResp = deletedListResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResp))
+	found = false
+	for _, record := range listResp.Data.List {
+		if record.ID != category.ID {
+			continue
+		}
+		found = true
+		restored := map[string]uint{}
+		for _, related := range record.Restores {
+			restored[related.Type] = related.ID
+		}
+		assert.Equal(t, field.ID, restored[model.ModelTypeField])
+		assert.Equal(t, item.ID, restored[model.ModelTypeItem])
+	}
+	assert.True(t, found, "deleted category not listed in recycle bin")
+
+	// 6. Purge the category with everything under it
+	w = performRequest("POST", "/deleted/purge", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": category.ID, "type": model.ModelTypeCategory}},
+	})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	testDB.Unscoped().Model(&model.Item{}).Where("id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Unscoped().Model(&model.ItemFieldValue{}).Where("field_id = ?", field.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDuplicateDeletedName(t *testing.T) {
+	// Prerequisite: a deleted tag
+	w := performRequest("POST", "/tag", map[string]string{"name": "Reused Tag"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "Reused Tag").First(&tag).Error)
+	w = performRequest("DELETE", fmt.Sprintf("/tag/%d", tag.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 1. Re-creating the name reports the deleted duplicate
+	var resp CommonResponse
+	w = performRequest("POST", "/tag", map[string]string{"name": "Reused Tag"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+	data, ok := resp.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(tag.ID), data["id"])
+	assert.Equal(t, true, data["isDeleted"])
+
+	// 2. Creating fresh is allowed despite the deleted row
+	w = performRequest("POST", "/tag", map[string]string{"name": "Reused Tag", "on_deleted": "create"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	var count int64
+	testDB.Unscoped().Model(&model.Tag{}).Where("name = ?", "Reused Tag").Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// 3. A live duplicate is never bypassed
+	w = performRequest("POST", "/tag", map[string]string{"name": "Reused Tag", "on_deleted": "create"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	// 4. The deleted tag cannot be restored while the live one holds the name
+	w = performRequest("POST", fmt.Sprintf("/tag/%d/restore", tag.ID), nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	// 5. Restoring the deleted category instead of creating a new one
+	w = performRequest("POST", "/category", map[string]string{"name": "Reused Category"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ?", "Reused Category").First(&category).Error)
+	w = performRequest("DELETE", fmt.Sprintf("/category/%d", category.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest("POST", "/category", map[string]string{"name": "Reused Category", "on_deleted": "restore"})
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+	testDB.Unscoped().Model(&model.Category{}).Where("name = ?", "Reused Category").Count(&count)
+	assert.Equal(t, int64(1), count)
+	testDB.Model(&model.Category{}).Where("id = ?", category.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	// Prerequisite: an item tagged with an old and a recent tag, both deleted
+	w := performRequest("POST", "/category", map[string]string{"name": "Retention Category"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ?", "Retention Category").First(&category).Error)
+	w = performRequest("POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Retention Item", "status": model.ItemStatusTodo},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "Retention Item").First(&item).Error)
+
+	var tags []model.Tag
+	for _, name := range []string{"Expired Tag", "Recent Tag"} {
+		w = performRequest("POST", "/tag", map[string]string{"name": name})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var tag model.Tag
+		require.NoError(t, testDB.Where("name = ?", name).First(&tag).Error)
+		w = performRequest("POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, tag.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = performRequest("DELETE", fmt.Sprintf("/tag/%d", tag.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		tags = append(tags, tag)
+	}
+	expired, recent := tags[0], tags[1]
+	require.NoError(t, testDB.Unscoped().Model(&model.Tag{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -40)).Error)
+
+	// 1. Only rows deleted before the cutoff are purged, with their join rows
+	require.NoError(t, service.PurgeExpired(time.Now().AddDate(0, 0, -30)))
+
+	var count int64
+	testDB.Unscoped().Model(&model.Tag{}).Where("id = ?", expired.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Table(model.JoinTableItemTags).Where("tag_id = ?", expired.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	testDB.Unscoped().Model(&model.Tag{}).Where("id = ?", recent.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	testDB.Table(model.JoinTableItemTags).Where("tag_id = ?", recent.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 2. Options of purged select fields are removed too
+	field := createTestField(t, category.ID, map[string]interface{}{
+		"name":    "Retention Select",
+		"type":    model.FieldTypeSelect,
+		"options": []map[string]string{{"value": "kept"}, {"value": "dropped"}},
+	})
+	w = performRequest("DELETE", fmt.Sprintf("/field/%d", field.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, testDB.Unscoped().Model(&model.Field{}).Where("id = ?", field.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -40)).Error)
+
+	require.NoError(t, service.PurgeExpired(time.Now().AddDate(0, 0, -30)))
+	testDB.Model(&model.FieldOption{}).Where("field_id = ?", field.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDeleteCategoryWithoutRecycleBin(t *testing.T) {
+	cfg := config.GetConfig()
+	cfg.RecycleBin.Enable = false
+	t.Cleanup(func() { cfg.RecycleBin.Enable = true })
+
+	// Prerequisite: a tagged, collected item referenced from another category
+	category := createTestCategory(t, "Hard Delete Category")
+	other := createTestCategory(t, "Hard Delete Other")
+	related := createTestField(t, other.ID, map[string]interface{}{
+		"name": "Hard Delete Related",
+		"type": model.FieldTypeReference,
+	})
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Hard Delete Item", "status": model.ItemStatusTodo},
+	}, ""))
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "Hard Delete Item").First(&item).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": other.ID,
+		"item": map[string]interface{}{
+			"name":   "Hard Delete Referrer",
+			"status": model.ItemStatusTodo,
+			"values": []map[string]interface{}{{"field_id": related.ID, "value": item.ID}},
+		},
+	}, ""))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Hard Delete Tag"}, ""))
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "Hard Delete Tag").First(&tag).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/tag/%d", item.ID, tag.ID), nil, ""))
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/collection", map[string]string{"name": "Hard Delete Collection"}, ""))
+	var collection model.Collection
+	require.NoError(t, testDB.Where("name = ?", "Hard Delete Collection").First(&collection).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/collection/%d", item.ID, collection.ID), nil, ""))
+
+	// 1. Deleting the category removes the item's associations and the values referencing it
+	require.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/category/%d", category.ID), nil, ""))
+
+	var count int64
+	testDB.Unscoped().Model(&model.Item{}).Where("id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Table(model.JoinTableItemTags).Where("item_id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Table(model.JoinTableCollectionItems).Where("item_id = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Unscoped().Model(&model.ItemFieldValue{}).Where("value_item = ?", item.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type itemReferencesResponse struct {
+	CommonResponse
+	Data struct {
+		ReferencedBy []struct {
+			ItemID    uint   `json:"item_id"`
+			ItemName  string `json:"item_name"`
+			FieldID   uint   `json:"field_id"`
+			FieldName string `json:"field_name"`
+		} `json:"referenced_by"`
+	} `json:"data"`
+}
+
+// --- Reference Field Tests ---
+
+func TestReferenceField(t *testing.T) {
+	series := createTestCategory(t, "Reference Series")
+	books := createTestCategory(t, "Reference Books")
+
+	// 1. Only reference fields can target an existing category
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", map[string]interface{}{
+		"category_id":        books.ID,
+		"name":               "Text Target",
+		"type":               model.FieldTypeString,
+		"target_category_id": series.ID,
+	}, ""))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", map[string]interface{}{
+		"category_id":        books.ID,
+		"name":               "Missing Target",
+		"type":               model.FieldTypeReference,
+		"target_category_id": 99999,
+	}, ""))
+
+	seriesField := createTestField(t, books.ID, map[string]interface{}{
+		"name":               "Series",
+		"type":               model.FieldTypeReference,
+		"target_category_id": series.ID,
+	})
+	related := createTestField(t, books.ID, map[string]interface{}{
+		"name":     "Related",
+		"type":     model.FieldTypeReference,
+		"is_array": true,
+	})
+
+	createItem := func(categoryID uint, name string, values []map[string]interface{}) int {
+		return requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": categoryID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo, "values": values},
+		}, "")
+	}
+	getItem := func(name string) model.Item {
+		var item model.Item
+		require.NoError(t, testDB.Where("name = ?", name).First(&item).Error)
+		return item
+	}
+
+	require.Equal(t, handler.SuccessCode, createItem(series.ID, "Reference Foundation", nil))
+	foundation := getItem("Reference Foundation")
+	require.Equal(t, handler.SuccessCode, createItem(books.ID, "Reference Prelude", nil))
+	prelude := getItem("Reference Prelude")
+
+	// 2. Targets must exist and belong to the target category
+	assert.Equal(t, handler.SuccessCode, createItem(books.ID, "Reference Empire", []map[string]interface{}{
+		{"field_id": seriesField.ID, "value": foundation.ID},
+		{"field_id": related.ID, "value": []uint{prelude.ID, foundation.ID, prelude.ID}},
+	}))
+	assert.Equal(t, handler.FailCode, createItem(books.ID, "Reference Wrong Category", []map[string]interface{}{
+		{"field_id": seriesField.ID, "value": prelude.ID},
+	}))
+	assert.Equal(t, handler.FailCode, createItem(books.ID, "Reference Missing", []map[string]interface{}{
+		{"field_id": related.ID, "value": []uint{99999}},
+	}))
+
+	// 3. Values are expanded into the target's ID and name
+	empire := getItem("Reference Empire")
+	values := getItemValues(t, empire.ID)
+	assert.Equal(t, map[string]interface{}{"id": float64(foundation.ID), "name": "Reference Foundation"}, values[seriesField.ID])
+	assert.Len(t, values[related.ID], 2)
+
+	// 4. Targets list the items referencing them
+	w := performRequest("GET", fmt.Sprintf("/item/%d", foundation.ID), nil)
+	var resp itemReferencesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code)
+	require.Len(t, resp.Data.ReferencedBy, 2)
+	assert.Equal(t, empire.ID, resp.Data.ReferencedBy[0].ItemID)
+	assert.Equal(t, "Reference Empire", resp.Data.ReferencedBy[0].ItemName)
+	assert.ElementsMatch(t, []string{"Series", "Related"},
+		[]string{resp.Data.ReferencedBy[0].FieldName, resp.Data.ReferencedBy[1].FieldName})
+
+	// 5. Filters match referenced item IDs
+	code, total := searchTotal(t, books.ID, map[uint]interface{}{seriesField.ID: foundation.ID})
+	require.Equal(t, handler.SuccessCode, code)
+	assert.Equal(t, int64(1), total)
+	_, total = searchTotal(t, books.ID, map[uint]interface{}{related.ID: []uint{prelude.ID}})
+	assert.Equal(t, int64(1), total)
+
+	// 6. Deleted sources are hidden, purged targets drop their references
+	require.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", empire.ID), nil, ""))
+	w = performRequest("GET", fmt.Sprintf("/item/%d", foundation.ID), nil)
+	resp = itemReferencesResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Data.ReferencedBy)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/deleted/restore", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": empire.ID, "type": model.ModelTypeItem}},
+	}, ""))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", foundation.ID), nil, ""))
+	assert.Nil(t, getItemValues(t, empire.ID)[seriesField.ID])
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/deleted/purge", map[string]interface{}{
+		"list": []map[string]interface{}{{"id": foundation.ID, "type": model.ModelTypeItem}},
+	}, ""))
+	var count int64
+	require.NoError(t, testDB.Model(&model.ItemFieldValue{}).Where("value_item = ?", foundation.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+	assert.Len(t, getItemValues(t, empire.ID)[related.ID], 1)
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Role Tests ---
+
+func TestRolePermissions(t *testing.T) {
+	enableAuth(t)
+	owner, ownerToken := createTestUser(t, "library-owner", model.UserRoleAdmin, 0)
+	editor, editorToken := createTestUser(t, "family-editor", model.UserRoleEditor, owner.ID)
+	_, viewerToken := createTestUser(t, "family-viewer", model.UserRoleViewer, owner.ID)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": "Family Shelf"}, ownerToken))
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ? AND user_id = ?", "Family Shelf", owner.ID).First(&category).Error)
+
+	// 1. Editors write into the shared library
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Shared Book", "status": model.ItemStatusTodo},
+	}, editorToken))
+	var item model.Item
+	require.NoError(t, testDB.Where("name = ?", "Shared Book").First(&item).Error)
+	assert.Equal(t, owner.ID, item.UserID)
+
+	// 2. Viewers may read and search the shared library but not modify it
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", fmt.Sprintf("/item/%d", item.ID), nil, viewerToken))
+	w := performAuthRequest("POST", "/item/search", map[string]interface{}{"name": "Shared"}, viewerToken)
+	var search searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &search))
+	assert.Equal(t, handler.SuccessCode, search.Code)
+	assert.Equal(t, int64(1), search.Data.Total)
+
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/item", map[string]interface{}{
+		"category_id": category.ID,
+		"item":        map[string]interface{}{"name": "Viewer Book", "status": model.ItemStatusTodo},
+	}, viewerToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", item.ID), nil, viewerToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Viewer Tag"}, viewerToken))
+
+	// 3. Editors can delete and restore but neither purge the recycle bin nor manage users
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", item.ID), nil, editorToken))
+	deletedList := map[string]interface{}{
+		"list": []map[string]interface{}{{"id": item.ID, "type": model.ModelTypeItem}},
+	}
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/deleted/purge", deletedList, editorToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/deleted/restore", deletedList, viewerToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/deleted/restore", deletedList, editorToken))
+
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/list", nil, editorToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user", map[string]interface{}{
+		"username": "editor-made",
+		"password": "password",
+		"role":     model.UserRoleAdmin,
+	}, editorToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/role", editor.ID), map[string]interface{}{
+		"role": model.UserRoleAdmin,
+	}, editorToken))
+
+	// 4. Admins change roles, taking effect on the next request
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/role", editor.ID), map[string]interface{}{
+		"role": model.UserRoleViewer,
+	}, ownerToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/tag", map[string]string{"name": "Editor Tag"}, editorToken))
+}
+
+func TestSharedLibraryOwner(t *testing.T) {
+	enableAuth(t)
+	owner, ownerToken := createTestUser(t, "shared-owner", model.UserRoleAdmin, 0)
+	member, _ := createTestUser(t, "shared-member", model.UserRoleViewer, owner.ID)
+
+	// A member cannot hand out access to the library it only shares
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user", map[string]interface{}{
+		"username":   "nested-member",
+		"password":   "password",
+		"role":       model.UserRoleViewer,
+		"library_id": member.ID,
+	}, ownerToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user", map[string]interface{}{
+		"username":   "orphan-member",
+		"password":   "password",
+		"role":       model.UserRoleViewer,
+		"library_id": 99999,
+	}, ownerToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user", map[string]interface{}{
+		"username":   "second-member",
+		"password":   "password",
+		"role":       model.UserRoleViewer,
+		"library_id": owner.ID,
+	}, ownerToken))
+}
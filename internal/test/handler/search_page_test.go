@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"collectify/internal/dao"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedItems 直接向数据库批量写入 n 个收藏品，名称为 "<prefix> 00000" 形式，评分为序号除以 11 的余数，
+// 整数字段 Pages 的值为序号除以 1000 的余数，返回分类和字段
+func seedItems(tb testing.TB, prefix string, n int) (model.Category, model.Field) {
+	w := performRequest("POST", "/category", map[string]string{"name": prefix + " Shelf"})
+	var resp CommonResponse
+	require.NoError(tb, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(tb, handler.SuccessCode, resp.Code, resp.Msg)
+	var category model.Category
+	require.NoError(tb, testDB.Where("name = ?", prefix+" Shelf").First(&category).Error)
+
+	field := model.Field{CategoryID: category.ID, Name: "Pages", Type: model.FieldTypeInt}
+	require.NoError(tb, testDB.Create(&field).Error)
+
+	// 在数据库中生成收藏品和字段值，名称只含 ASCII 字符，搜索键即小写的名称
+	now := time.Now()
+	err := testDB.Exec(`WITH RECURSIVE seq(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM seq WHERE i + 1 < ?)
+		INSERT INTO items (created_at, updated_at, user_id, name, name_key, category_id, status, rating)
+		SELECT ?, ?, ?, printf('%s %05d', ?, i), lower(printf('%s %05d', ?, i)), ?, ?, i % 11 FROM seq`,
+		n, now, now, category.UserID, prefix, prefix, category.ID, model.ItemStatusTodo).Error
+	require.NoError(tb, err)
+	err = testDB.Exec(`INSERT INTO item_field_values (created_at, updated_at, item_id, field_id, value_int)
+		SELECT ?, ?, id, ?, CAST(substr(name, -5) AS INTEGER) % 1000 FROM items WHERE category_id = ?`,
+		now, now, field.ID, category.ID).Error
+	require.NoError(tb, err)
+	return category, field
+}
+
+// --- Search Pagination Tests ---
+
+func TestSearchItemsLargeResult(t *testing.T) {
+	// More matching items than SQLite allows query parameters
+	category, field := seedItems(t, "Paged", 33000)
+
+	search := func(body map[string]interface{}) searchResponse {
+		body["category_id"] = category.ID
+		w := performRequest("POST", "/item/search", body)
+		var resp searchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, handler.SuccessCode, resp.Code, resp.Msg)
+		return resp
+	}
+	names := func(resp searchResponse) []string {
+		var names []string
+		for _, item := range resp.Data.List {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	// 1. Pages keep the requested order and report the total
+	resp := search(map[string]interface{}{"sort": "name", "page": 3, "page_size": 3})
+	assert.Equal(t, int64(33000), resp.Data.Total)
+	assert.Equal(t, []string{"Paged 00006", "Paged 00007", "Paged 00008"}, names(resp))
+
+	resp = search(map[string]interface{}{"sort": "-rating,-name", "page": 1, "page_size": 2})
+	assert.Equal(t, []string{"Paged 32999", "Paged 32988"}, names(resp))
+
+	// 2. Filters on item columns, field values and names combine
+	resp = search(map[string]interface{}{
+		"filters":   map[string]interface{}{fmt.Sprint(field.ID): map[string]interface{}{"op": "gte", "value": 998}},
+		"q":         "rating>=9",
+		"sort":      "name",
+		"page":      1,
+		"page_size": 2,
+	})
+	assert.Equal(t, int64(12), resp.Data.Total)
+	assert.Equal(t, []string{"Paged 00999", "Paged 09998"}, names(resp))
+
+	resp = search(map[string]interface{}{"name": "paged 3299", "sort": "-name", "page": 1, "page_size": 1})
+	assert.Equal(t, int64(10), resp.Data.Total)
+	assert.Equal(t, []string{"Paged 32999"}, names(resp))
+
+	// 3. A page past the end is empty but still reports the total
+	resp = search(map[string]interface{}{"page": 2000, "page_size": 20})
+	assert.Empty(t, resp.Data.List)
+	assert.Equal(t, int64(33000), resp.Data.Total)
+}
+
+func BenchmarkSearchItems(b *testing.B) {
+	category, field := seedItems(b, "Bench", 100000)
+	categoryFilter := dao.Filter{Where: "items.category_id = ?", Args: []interface{}{category.ID}}
+	require.NoError(b, dao.IndexItemsByFilter(testDB, categoryFilter))
+
+	benchmarks := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"category", map[string]interface{}{}},
+		{"name", map[string]interface{}{"name": "0042"}},
+		{"field filter", map[string]interface{}{"filters": map[string]interface{}{fmt.Sprint(field.ID): map[string]interface{}{"op": "gte", "value": 900}}}},
+		{"field sort", map[string]interface{}{"sort": fmt.Sprintf("-field:%d", field.ID)}},
+		{"query", map[string]interface{}{"q": "rating>=8 AND pages<100"}},
+		{"full text", map[string]interface{}{"text": "bench 0004"}},
+		{"deep page", map[string]interface{}{"sort": "name", "page": 4000}},
+	}
+	for _, bm := range benchmarks {
+		bm.body["category_id"] = category.ID
+		bm.body["page_size"] = 20
+		if bm.body["page"] == nil {
+			bm.body["page"] = 1
+		}
+
+		w := performRequest("POST", "/item/search", bm.body)
+		var resp searchResponse
+		require.NoError(b, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(b, handler.SuccessCode, resp.Code, resp.Msg)
+
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				performRequest("POST", "/item/search", bm.body)
+			}
+		})
+	}
+}
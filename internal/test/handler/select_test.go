@@ -0,0 +1,183 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type categoryFieldsResponse struct {
+	CommonResponse
+	Data struct {
+		Fields []struct {
+			ID      uint   `json:"id"`
+			Name    string `json:"name"`
+			Options []struct {
+				ID    uint   `json:"id"`
+				Value string `json:"value"`
+				Color string `json:"color"`
+			} `json:"options"`
+		} `json:"fields"`
+	} `json:"data"`
+}
+
+type itemValuesResponse struct {
+	CommonResponse
+	Data struct {
+		Values []struct {
+			FieldID uint        `json:"field_id"`
+			Value   interface{} `json:"value"`
+		} `json:"values"`
+	} `json:"data"`
+}
+
+// createTestCategory creates a category and returns it.
+func createTestCategory(t *testing.T, name string) model.Category {
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": name}, ""))
+	var category model.Category
+	require.NoError(t, testDB.Where("name = ?", name).First(&category).Error)
+	return category
+}
+
+// createTestField creates a field and returns it.
+func createTestField(t *testing.T, categoryID uint, req map[string]interface{}) model.Field {
+	req["category_id"] = categoryID
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/field", req, ""))
+	var field model.Field
+	require.NoError(t, testDB.Where("category_id = ? AND name = ?", categoryID, req["name"]).First(&field).Error)
+	return field
+}
+
+func getItemValues(t *testing.T, itemID uint) map[uint]interface{} {
+	w := performRequest("GET", fmt.Sprintf("/item/%d", itemID), nil)
+	var resp itemValuesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code)
+
+	values := make(map[uint]interface{})
+	for _, value := range resp.Data.Values {
+		values[value.FieldID] = value.Value
+	}
+	return values
+}
+
+func searchTotal(t *testing.T, categoryID uint, filters map[uint]interface{}) (int, int64) {
+	w := performRequest("POST", "/item/search", map[string]interface{}{
+		"category_id": categoryID,
+		"filters":     filters,
+	})
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Code, resp.Data.Total
+}
+
+// --- Select Field Tests ---
+
+func TestSelectField(t *testing.T) {
+	category := createTestCategory(t, "Select Shelf")
+
+	// 1. Select fields need distinct options, other types cannot have any
+	for _, req := range []map[string]interface{}{
+		{"name": "No Options", "type": model.FieldTypeSelect},
+		{"name": "Duplicated", "type": model.FieldTypeSelect, "options": []map[string]string{{"value": "a"}, {"value": "a"}}},
+		{"name": "Bad Color", "type": model.FieldTypeSelect, "options": []map[string]string{{"value": "a", "color": "red"}}},
+		{"name": "Text", "type": model.FieldTypeString, "options": []map[string]string{{"value": "a"}}},
+	} {
+		req["category_id"] = category.ID
+		assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/field", req, ""), req["name"])
+	}
+
+	format := createTestField(t, category.ID, map[string]interface{}{
+		"name": "Format",
+		"type": model.FieldTypeSelect,
+		"options": []map[string]string{
+			{"value": "hardcover", "color": "#8b0000"},
+			{"value": "paperback"},
+			{"value": "ebook"},
+		},
+	})
+	editions := createTestField(t, category.ID, map[string]interface{}{
+		"name":     "Editions",
+		"type":     model.FieldTypeSelect,
+		"is_array": true,
+		"options":  []map[string]string{{"value": "first"}, {"value": "signed"}, {"value": "limited"}},
+	})
+
+	w := performRequest("GET", fmt.Sprintf("/category/%d", category.ID), nil)
+	var categoryResp categoryFieldsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &categoryResp))
+	require.Len(t, categoryResp.Data.Fields, 2)
+	options := categoryResp.Data.Fields[0].Options
+	require.Len(t, options, 3)
+	assert.Equal(t, "hardcover", options[0].Value)
+	assert.Equal(t, "#8b0000", options[0].Color)
+	assert.Equal(t, "ebook", options[2].Value)
+	hardcoverID, paperbackID := options[0].ID, options[1].ID
+
+	// 2. Values must be one of the options, given by name or ID
+	createItem := func(name string, values []map[string]interface{}) int {
+		return requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item":        map[string]interface{}{"name": name, "status": model.ItemStatusTodo, "values": values},
+		}, "")
+	}
+	assert.Equal(t, handler.SuccessCode, createItem("Select Dune", []map[string]interface{}{
+		{"field_id": format.ID, "value": "hardcover"},
+		{"field_id": editions.ID, "value": []string{"first", "signed", "first"}},
+	}))
+	assert.Equal(t, handler.SuccessCode, createItem("Select Emma", []map[string]interface{}{
+		{"field_id": format.ID, "value": paperbackID},
+	}))
+	assert.Equal(t, handler.FailCode, createItem("Select Typo", []map[string]interface{}{
+		{"field_id": format.ID, "value": "hardcovr"},
+	}))
+	assert.Equal(t, handler.FailCode, createItem("Select Many", []map[string]interface{}{
+		{"field_id": format.ID, "value": []string{"hardcover", "ebook"}},
+	}))
+
+	var dune model.Item
+	require.NoError(t, testDB.Where("name = ?", "Select Dune").First(&dune).Error)
+	values := getItemValues(t, dune.ID)
+	assert.Equal(t, "hardcover", values[format.ID])
+	assert.ElementsMatch(t, []interface{}{"first", "signed"}, values[editions.ID])
+
+	// 3. Filters match options exactly
+	code, total := searchTotal(t, category.ID, map[uint]interface{}{format.ID: "hardcover"})
+	require.Equal(t, handler.SuccessCode, code)
+	assert.Equal(t, int64(1), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{format.ID: []interface{}{"hardcover", paperbackID}})
+	assert.Equal(t, int64(2), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{format.ID: "hard"})
+	assert.Equal(t, int64(0), total)
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{editions.ID: "signed"})
+	assert.Equal(t, int64(1), total)
+
+	// 4. Renaming and reordering options keeps existing values
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/field/%d/options", format.ID), map[string]interface{}{
+		"options": []map[string]interface{}{
+			{"id": paperbackID, "value": "paperback"},
+			{"id": hardcoverID, "value": "hard cover", "color": "#000000"},
+			{"value": "audiobook"},
+		},
+	}, ""))
+	assert.Equal(t, "hard cover", getItemValues(t, dune.ID)[format.ID])
+	_, total = searchTotal(t, category.ID, map[uint]interface{}{format.ID: "hard cover"})
+	assert.Equal(t, int64(1), total)
+
+	w = performRequest("GET", fmt.Sprintf("/category/%d", category.ID), nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &categoryResp))
+	options = categoryResp.Data.Fields[0].Options
+	require.Len(t, options, 3)
+	assert.Equal(t, []string{"paperback", "hard cover", "audiobook"}, []string{options[0].Value, options[1].Value, options[2].Value})
+
+	// 5. Options in use cannot be removed
+	assert.Equal(t, handler.FailCode, requestCode(t, "PUT", fmt.Sprintf("/field/%d/options", format.ID), map[string]interface{}{
+		"options": []map[string]interface{}{{"id": paperbackID, "value": "paperback"}},
+	}, ""))
+	assert.Equal(t, "hard cover", getItemValues(t, dune.ID)[format.ID])
+}
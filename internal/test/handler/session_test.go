@@ -0,0 +1,130 @@
+package handler_test
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenPairResponse struct {
+	CommonResponse
+	Data struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		ID           uint   `json:"id"`
+	} `json:"data"`
+}
+
+func requestTokenPair(t *testing.T, target string, body interface{}) tokenPairResponse {
+	w := performRequest("POST", target, body)
+	var resp tokenPairResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// --- Session Tests ---
+
+func TestLogoutRevokesToken(t *testing.T) {
+	enableAuth(t)
+	createTestUser(t, "session-user", model.UserRoleAdmin, 0)
+
+	first := login(t, "session-user", "password").Data.Token
+	second := login(t, "session-user", "password").Data.Token
+
+	// 1. Logging out only revokes the current session
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/logout", nil, first))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, first))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, second))
+
+	// 2. Logging out everywhere revokes all sessions
+	third := login(t, "session-user", "password").Data.Token
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/logout?all=true", nil, second))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, second))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, third))
+
+	// 3. Tokens without a session are rejected
+	var user model.User
+	require.NoError(t, testDB.Where("username = ?", "session-user").First(&user).Error)
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":       user.ID,
+		"username": user.Username,
+	}).SignedString([]byte(config.GetConfig().Auth.JwtSecret))
+	require.NoError(t, err)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, legacy))
+}
+
+func TestPasswordChangeRevokesOtherSessions(t *testing.T) {
+	enableAuth(t)
+	user, current := createTestUser(t, "session-changer", model.UserRoleAdmin, 0)
+	other := login(t, "session-changer", "password").Data.Token
+	pair := requestTokenPair(t, "/user/token", map[string]string{
+		"username": "session-changer",
+		"password": "password",
+	})
+	require.Equal(t, handler.SuccessCode, pair.Code)
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/update", map[string]interface{}{
+		"id":               user.ID,
+		"username":         "session-changer",
+		"current_password": "password",
+		"password":         "changed-password",
+	}, current))
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, current))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, other))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, pair.Data.AccessToken))
+	assert.Equal(t, handler.FailCode, requestTokenPair(t, "/user/token/refresh", map[string]string{
+		"refresh_token": pair.Data.RefreshToken,
+	}).Code)
+}
+
+func TestRefreshTokenPair(t *testing.T) {
+	enableAuth(t)
+	config.GetConfig().Auth.AccessTokenTTL = time.Minute
+	createTestUser(t, "session-refresher", model.UserRoleAdmin, 0)
+
+	// 1. Credentials are exchanged for a short-lived access token and a refresh token
+	assert.Equal(t, handler.FailCode, requestTokenPair(t, "/user/token", map[string]string{
+		"username": "session-refresher",
+		"password": "wrong-password",
+	}).Code)
+	pair := requestTokenPair(t, "/user/token", map[string]string{
+		"username": "session-refresher",
+		"password": "password",
+	})
+	require.Equal(t, handler.SuccessCode, pair.Code)
+	assert.Equal(t, int64(60), pair.Data.ExpiresIn)
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, "Bearer "+pair.Data.AccessToken))
+
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(pair.Data.AccessToken, claims)
+	require.NoError(t, err)
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp.Time, 5*time.Second)
+
+	// 2. Refresh tokens rotate on use
+	refreshed := requestTokenPair(t, "/user/token/refresh", map[string]string{
+		"refresh_token": pair.Data.RefreshToken,
+	})
+	require.Equal(t, handler.SuccessCode, refreshed.Code)
+	assert.NotEqual(t, pair.Data.RefreshToken, refreshed.Data.RefreshToken)
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, refreshed.Data.AccessToken))
+	assert.Equal(t, handler.FailCode, requestTokenPair(t, "/user/token/refresh", map[string]string{
+		"refresh_token": pair.Data.RefreshToken,
+	}).Code)
+
+	// 3. Logging out invalidates the refresh token as well
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/logout", nil, refreshed.Data.AccessToken))
+	assert.Equal(t, handler.FailCode, requestTokenPair(t, "/user/token/refresh", map[string]string{
+		"refresh_token": refreshed.Data.RefreshToken,
+	}).Code)
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchNames returns the names of the items found in the given order.
+func searchNames(t *testing.T, body map[string]interface{}) []string {
+	w := performRequest("POST", "/item/search", body)
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code, resp.Msg)
+
+	names := make([]string, len(resp.Data.List))
+	for i, item := range resp.Data.List {
+		names[i] = item.Name
+	}
+	return names
+}
+
+// --- Sort Tests ---
+
+func TestSortItems(t *testing.T) {
+	category := createTestCategory(t, "Sort Shelf")
+	year := createTestField(t, category.ID, map[string]interface{}{"name": "Year", "type": model.FieldTypeInt})
+	format := createTestField(t, category.ID, map[string]interface{}{
+		"name":    "Format",
+		"type":    model.FieldTypeSelect,
+		"options": []map[string]string{{"value": "paperback"}, {"value": "hardcover"}},
+	})
+
+	createItem := func(name string, status int, rating interface{}, values []map[string]interface{}) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item": map[string]interface{}{
+				"name":   name,
+				"status": status,
+				"rating": rating,
+				"values": values,
+			},
+		}, ""))
+	}
+	createItem("Sort B", model.ItemStatusTodo, 8, []map[string]interface{}{
+		{"field_id": year.ID, "value": 1990},
+		{"field_id": format.ID, "value": "hardcover"},
+	})
+	createItem("Sort A", model.ItemStatusCompleted, nil, []map[string]interface{}{
+		{"field_id": format.ID, "value": "paperback"},
+	})
+	createItem("Sort C", model.ItemStatusTodo, 5, []map[string]interface{}{
+		{"field_id": year.ID, "value": 1970},
+	})
+
+	sorted := func(sort string) []string {
+		return searchNames(t, map[string]interface{}{"category_id": category.ID, "sort": sort})
+	}
+
+	// 1. Item attributes, with empty values last in both directions
+	assert.Equal(t, []string{"Sort A", "Sort B", "Sort C"}, sorted("name"))
+	assert.Equal(t, []string{"Sort C", "Sort B", "Sort A"}, sorted("-name"))
+	assert.Equal(t, []string{"Sort C", "Sort B", "Sort A"}, sorted("rating"))
+	assert.Equal(t, []string{"Sort B", "Sort C", "Sort A"}, sorted("-rating"))
+	assert.Equal(t, []string{"Sort C", "Sort A", "Sort B"}, sorted("-updated_at"))
+
+	// 2. Several keys
+	assert.Equal(t, []string{"Sort B", "Sort C", "Sort A"}, sorted("status, -rating"))
+	assert.Equal(t, []string{"Sort B", "Sort C", "Sort A"}, sorted("status,name"))
+
+	// 3. Custom fields sort by their typed value, selects by option order
+	assert.Equal(t, []string{"Sort C", "Sort B", "Sort A"}, sorted(fmt.Sprintf("field:%d", year.ID)))
+	assert.Equal(t, []string{"Sort B", "Sort C", "Sort A"}, sorted(fmt.Sprintf("-field:%d", year.ID)))
+	assert.Equal(t, []string{"Sort A", "Sort B", "Sort C"}, sorted(fmt.Sprintf("field:%d", format.ID)))
+
+	// 4. Sorting also works with paging
+	page := searchNames(t, map[string]interface{}{"category_id": category.ID, "sort": "name", "page": 2, "page_size": 2})
+	assert.Equal(t, []string{"Sort C"}, page)
+
+	// 5. Unknown keys and fields of other categories are rejected
+	for _, sort := range []string{"color", "field:abc", "field:99999"} {
+		code, _ := searchItemsTotal(t, map[string]interface{}{"category_id": category.ID, "sort": sort})
+		assert.Equal(t, handler.FailCode, code, sort)
+	}
+	code, _ := searchItemsTotal(t, map[string]interface{}{"sort": fmt.Sprintf("field:%d", year.ID)})
+	assert.Equal(t, handler.FailCode, code)
+
+	// 6. The item list accepts the same keys
+	w := performRequest("GET", "/item/list?page=1&page_size=100&sort=-priority,name", nil)
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, handler.SuccessCode, resp.Code)
+	assert.NotEmpty(t, resp.Data.List)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", fmt.Sprintf("/item/list?page=1&page_size=10&sort=field:%d", year.ID), nil, ""))
+}
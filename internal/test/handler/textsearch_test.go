@@ -0,0 +1,99 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snippetResponse struct {
+	CommonResponse
+	Data struct {
+		List []struct {
+			Name    string `json:"name"`
+			Snippet string `json:"snippet"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+// --- Full-Text Search Tests ---
+
+func TestFullTextSearch(t *testing.T) {
+	category := createTestCategory(t, "Text Shelf")
+	author := createTestField(t, category.ID, map[string]interface{}{"name": "Author", "type": model.FieldTypeString})
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/tag", map[string]string{"name": "textclassic"}, ""))
+	var tag model.Tag
+	require.NoError(t, testDB.Where("name = ?", "textclassic").First(&tag).Error)
+
+	createItem := func(name, description, notes, authorName string) model.Item {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item": map[string]interface{}{
+				"name":        name,
+				"status":      model.ItemStatusTodo,
+				"description": description,
+				"notes":       notes,
+				"values":      []map[string]interface{}{{"field_id": author.ID, "value": authorName}},
+			},
+		}, ""))
+		var item model.Item
+		require.NoError(t, testDB.Where("name = ?", name).First(&item).Error)
+		return item
+	}
+	dune := createItem("Text Dune", "A desert planet epic", "spice must flow", "Frank Herbert")
+	createItem("Text 三体", "地球往事三部曲的第一部", "", "刘慈欣")
+	foundation := createItem("Text Foundation", "Psychohistory", "Mentions dune in passing", "Isaac Asimov")
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/tag/%d", foundation.ID, tag.ID), nil, ""))
+
+	search := func(text string, extra ...interface{}) []string {
+		body := map[string]interface{}{"category_id": category.ID, "text": text}
+		for i := 0; i+1 < len(extra); i += 2 {
+			body[extra[i].(string)] = extra[i+1]
+		}
+		return searchNames(t, body)
+	}
+
+	// 1. Name, description, notes, tags and string fields are searched, best matches first
+	assert.Equal(t, []string{"Text Dune", "Text Foundation"}, search("DUNE"))
+	assert.Equal(t, []string{"Text Foundation", "Text Dune"}, search("dune", "sort", "-name"))
+	assert.Equal(t, []string{"Text Dune"}, search("herbert"))
+	assert.Equal(t, []string{"Text Dune"}, search("planet spice"))
+	assert.Empty(t, search("planet psychohistory"))
+	assert.Equal(t, []string{"Text Foundation"}, search("textclassic"))
+	assert.Equal(t, []string{"Text 三体"}, search("三部曲"))
+	assert.Equal(t, []string{"Text 三体"}, search("刘慈"))
+	assert.Empty(t, search(`"dune`))
+
+	// 2. Matches are highlighted in snippets
+	w := performRequest("POST", "/item/search", map[string]interface{}{"category_id": category.ID, "text": "psychohistory"})
+	var resp snippetResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.List, 1)
+	assert.Contains(t, resp.Data.List[0].Snippet, "<mark>Psychohistory</mark>")
+
+	// 3. The index follows updates, tag changes and deletion
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", dune.ID), map[string]interface{}{
+		"id":   dune.ID,
+		"item": map[string]interface{}{"name": dune.Name, "status": model.ItemStatusTodo, "notes": "sandworms"},
+	}, ""))
+	assert.Equal(t, []string{"Text Dune"}, search("sandworms"))
+	assert.Empty(t, search("spice"))
+	assert.Empty(t, search("herbert"))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PATCH", fmt.Sprintf("/tag/%d", tag.ID), map[string]string{"name": "textrenamed"}, ""))
+	assert.Empty(t, search("textclassic"))
+	assert.Equal(t, []string{"Text Foundation"}, search("textrenamed"))
+	require.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d/tag/%d", foundation.ID, tag.ID), nil, ""))
+	assert.Empty(t, search("textrenamed"))
+
+	require.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/item/%d", foundation.ID), nil, ""))
+	assert.Empty(t, search("psychohistory"))
+	require.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/item/%d/restore", foundation.ID), nil, ""))
+	assert.Equal(t, []string{"Text Foundation"}, search("psychohistory"))
+}
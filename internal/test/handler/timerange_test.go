@@ -0,0 +1,149 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/timerange"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchItemsTotal(t *testing.T, body map[string]interface{}) (int, int64) {
+	w := performRequest("POST", "/item/search", body)
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Code, resp.Data.Total
+}
+
+// --- Time Range Tests ---
+
+func TestTimeRangeParse(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339Nano, s)
+		require.NoError(t, err)
+		return v
+	}
+
+	tests := []struct {
+		value      interface{}
+		start, end string
+	}{
+		{"today", "2024-05-15T00:00:00Z", "2024-05-15T23:59:59.999999999Z"},
+		{"Yesterday", "2024-05-14T00:00:00Z", "2024-05-14T23:59:59.999999999Z"},
+		{"last 30 days", "2024-04-15T10:30:00Z", "2024-05-15T10:30:00Z"},
+		{"last  week", "2024-05-08T10:30:00Z", "2024-05-15T10:30:00Z"},
+		{"this week", "2024-05-13T00:00:00Z", "2024-05-15T10:30:00Z"},
+		{"this year", "2024-01-01T00:00:00Z", "2024-05-15T10:30:00Z"},
+		{"2024-02-29", "2024-02-29T00:00:00Z", "2024-02-29T23:59:59.999999999Z"},
+		{"2024-02-29T08:00:00+08:00", "2024-02-29T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{map[string]interface{}{"start": "2024-01-01", "end": "2024-01-31"}, "2024-01-01T00:00:00Z", "2024-01-31T23:59:59.999999999Z"},
+		{map[string]interface{}{"start": "2 weeks ago"}, "2024-05-01T10:30:00Z", ""},
+		{map[string]interface{}{"end": "now"}, "", "2024-05-15T10:30:00Z"},
+	}
+	for _, tt := range tests {
+		r, err := timerange.Parse(tt.value, now)
+		require.NoError(t, err, tt.value)
+		if tt.start == "" {
+			assert.Nil(t, r.Start, tt.value)
+		} else {
+			require.NotNil(t, r.Start, tt.value)
+			assert.True(t, at(tt.start).Equal(*r.Start), "%v: start %v", tt.value, r.Start)
+		}
+		if tt.end == "" {
+			assert.Nil(t, r.End, tt.value)
+		} else {
+			require.NotNil(t, r.End, tt.value)
+			assert.True(t, at(tt.end).Equal(*r.End), "%v: end %v", tt.value, r.End)
+		}
+	}
+
+	for _, invalid := range []interface{}{
+		"next week",
+		"2024-13-01",
+		map[string]interface{}{},
+		map[string]interface{}{"start": "2024-02-01", "end": "2024-01-01"},
+		map[string]interface{}{"start": 20240101},
+		42,
+	} {
+		_, err := timerange.Parse(invalid, now)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDatetimeSearch(t *testing.T) {
+	category := createTestCategory(t, "Datetime Shelf")
+	released := createTestField(t, category.ID, map[string]interface{}{"name": "Released", "type": model.FieldTypeDatetime})
+
+	createItem := func(name string, value string) {
+		require.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/item", map[string]interface{}{
+			"category_id": category.ID,
+			"item": map[string]interface{}{
+				"name":   name,
+				"status": model.ItemStatusTodo,
+				"values": []map[string]interface{}{{"field_id": released.ID, "value": value}},
+			},
+		}, ""))
+	}
+	createItem("Datetime Old", "1999-06-01T00:00:00Z")
+	createItem("Datetime Recent", time.Now().AddDate(0, 0, -3).Format(time.RFC3339))
+
+	// 1. Field filters accept JSON ranges, open ends and relative expressions
+	for _, tt := range []struct {
+		value interface{}
+		want  int64
+	}{
+		{map[string]interface{}{"start": "1999-01-01", "end": "1999-12-31"}, 1},
+		{map[string]interface{}{"start": "1999-06-02"}, 1},
+		{map[string]interface{}{"end": "1999-06-01"}, 1},
+		{"1999-06-01", 1},
+		{"last 7 days", 1},
+		{"last 2 days", 0},
+		{map[string]interface{}{"op": "lt", "value": "1 year ago"}, 1},
+	} {
+		code, total := searchTotal(t, category.ID, map[uint]interface{}{released.ID: tt.value})
+		require.Equal(t, handler.SuccessCode, code, tt.value)
+		assert.Equal(t, tt.want, total, tt.value)
+	}
+	code, _ := searchTotal(t, category.ID, map[uint]interface{}{released.ID: "sometime"})
+	assert.Equal(t, handler.FailCode, code)
+
+	// 2. Items can be filtered by creation and completion time
+	code, total := searchItemsTotal(t, map[string]interface{}{"category_id": category.ID, "created_at": "today"})
+	require.Equal(t, handler.SuccessCode, code)
+	assert.Equal(t, int64(2), total)
+	_, total = searchItemsTotal(t, map[string]interface{}{"category_id": category.ID, "created_at": map[string]interface{}{"end": "2000-01-01"}})
+	assert.Equal(t, int64(0), total)
+	_, total = searchItemsTotal(t, map[string]interface{}{"category_id": category.ID, "completed_at": "last 7 days"})
+	assert.Equal(t, int64(0), total)
+
+	var old model.Item
+	require.NoError(t, testDB.Where("name = ?", "Datetime Old").First(&old).Error)
+	require.Equal(t, handler.SuccessCode, requestCode(t, "PUT", fmt.Sprintf("/item/%d", old.ID), map[string]interface{}{
+		"id":   old.ID,
+		"item": map[string]interface{}{"name": old.Name, "status": model.ItemStatusCompleted},
+	}, ""))
+	_, total = searchItemsTotal(t, map[string]interface{}{"category_id": category.ID, "completed_at": "last 7 days"})
+	assert.Equal(t, int64(1), total)
+
+	code, _ = searchItemsTotal(t, map[string]interface{}{"created_at": "whenever"})
+	assert.Equal(t, handler.FailCode, code)
+
+	// 3. Timestamps saved under another UTC offset compare by instant, not by text
+	require.NoError(t, testDB.Exec("UPDATE items SET created_at = ? WHERE id = ?", "2021-06-01 02:00:00+08:00", old.ID).Error)
+	_, total = searchItemsTotal(t, map[string]interface{}{
+		"category_id": category.ID,
+		"created_at":  map[string]interface{}{"start": "2021-05-31T17:00:00Z", "end": "2021-05-31T19:00:00Z"},
+	})
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Datetime Old"}, searchNames(t, map[string]interface{}{
+		"category_id": category.ID,
+		"q":           `created<"2021-06-01T00:00:00Z"`,
+	}))
+}
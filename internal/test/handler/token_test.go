@@ -0,0 +1,119 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type apiTokenResponse struct {
+	CommonResponse
+	Data struct {
+		ID         uint    `json:"id"`
+		Name       string  `json:"name"`
+		Prefix     string  `json:"prefix"`
+		Scope      string  `json:"scope"`
+		LastUsedAt *string `json:"last_used_at"`
+		Token      string  `json:"token"`
+	} `json:"data"`
+}
+
+type apiTokenListResponse struct {
+	CommonResponse
+	Data struct {
+		List []struct {
+			ID         uint    `json:"id"`
+			Name       string  `json:"name"`
+			Scope      string  `json:"scope"`
+			LastUsedAt *string `json:"last_used_at"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	} `json:"data"`
+}
+
+func createAPIToken(t *testing.T, name, scope, token string) apiTokenResponse {
+	w := performAuthRequest("POST", "/user/tokens", map[string]string{
+		"name":  name,
+		"scope": scope,
+	}, token)
+	var resp apiTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// --- API Token Tests ---
+
+func TestAPITokens(t *testing.T) {
+	enableAuth(t)
+	owner, sessionToken := createTestUser(t, "script-owner", model.UserRoleAdmin, 0)
+	_, otherToken := createTestUser(t, "script-other", model.UserRoleAdmin, 0)
+
+	// 1. Tokens are returned once and only their hash is stored
+	write := createAPIToken(t, "cron import", model.APITokenScopeWrite, sessionToken)
+	require.Equal(t, handler.SuccessCode, write.Code)
+	assert.True(t, strings.HasPrefix(write.Data.Token, "clt_"))
+	assert.True(t, strings.HasPrefix(write.Data.Token, write.Data.Prefix))
+	var stored model.APIToken
+	require.NoError(t, testDB.First(&stored, write.Data.ID).Error)
+	assert.NotEqual(t, write.Data.Token, stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, write.Data.Token)
+
+	read := createAPIToken(t, "dashboard", model.APITokenScopeRead, sessionToken)
+	require.Equal(t, handler.SuccessCode, read.Code)
+	assert.Equal(t, handler.FailCode, createAPIToken(t, "invalid", "admin", sessionToken).Code)
+
+	writeBearer := "Bearer " + write.Data.Token
+	readBearer := "Bearer " + read.Data.Token
+
+	// 2. Scopes limit what tokens can do
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/category", map[string]string{"name": "Imported"}, writeBearer))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, readBearer))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/category", map[string]string{"name": "Read Only"}, readBearer))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/list", nil, writeBearer))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/deleted/purge", map[string]interface{}{
+		"list": []map[string]interface{}{},
+	}, writeBearer))
+
+	// 3. Tokens cannot manage tokens or change the password
+	assert.Equal(t, handler.FailCode, createAPIToken(t, "nested", model.APITokenScopeWrite, writeBearer).Code)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, writeBearer))
+
+	// 4. JWTs are accepted with or without the Bearer prefix
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, "Bearer "+sessionToken))
+
+	w := performAuthRequest("GET", "/user/tokens", nil, sessionToken)
+	var list apiTokenListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Equal(t, int64(2), list.Data.Total)
+	for _, token := range list.Data.List {
+		assert.NotNil(t, token.LastUsedAt, token.Name)
+	}
+	assert.NotContains(t, w.Body.String(), write.Data.Token)
+
+	// 5. Tokens can only be revoked by their owner, after which they are rejected
+	assert.Equal(t, handler.FailCode, requestCode(t, "DELETE", fmt.Sprintf("/user/tokens/%d", write.Data.ID), nil, otherToken))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, writeBearer))
+
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "DELETE", fmt.Sprintf("/user/tokens/%d", write.Data.ID), nil, sessionToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/category", map[string]string{"name": "Revoked"}, writeBearer))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/category/list", nil, "Bearer clt_unknown"))
+
+	// 6. Changing the password revokes the remaining tokens
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/category/list", nil, readBearer))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/update", map[string]interface{}{
+		"id":               owner.ID,
+		"username":         "script-owner",
+		"current_password": "password",
+		"password":         "rotated-password",
+	}, sessionToken))
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/category/list", nil, readBearer))
+	var count int64
+	testDB.Model(&model.APIToken{}).Where("user_id = ?", owner.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
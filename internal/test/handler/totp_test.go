@@ -0,0 +1,225 @@
+package handler_test
+
+import (
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"collectify/internal/pkg/totp"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type totpSetupResponse struct {
+	CommonResponse
+	Data struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	} `json:"data"`
+}
+
+type totpRecoveryCodesResponse struct {
+	CommonResponse
+	Data struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	} `json:"data"`
+}
+
+type loginChallengeResponse struct {
+	CommonResponse
+	Data struct {
+		TOTPRequired bool   `json:"totp_required"`
+		Challenge    string `json:"challenge"`
+		Token        string `json:"token"`
+	} `json:"data"`
+}
+
+// totpCode returns the code for the given number of time steps from now.
+func totpCode(t *testing.T, secret string, steps int64) string {
+	code, err := totp.Code(secret, totp.Counter(time.Now())+steps)
+	require.NoError(t, err)
+	return code
+}
+
+func loginChallenge(t *testing.T, target, username, password string) loginChallengeResponse {
+	w := performRequest("POST", target, map[string]string{
+		"username": username,
+		"password": password,
+	})
+	var resp loginChallengeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func loginTOTP(t *testing.T, challenge, code string) loginResponse {
+	w := performRequest("POST", "/user/login/totp", map[string]string{
+		"challenge": challenge,
+		"code":      code,
+	})
+	var resp loginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// enableTOTP sets up two-factor authentication and returns the secret and recovery codes.
+func enableTOTP(t *testing.T, token string) (string, []string) {
+	w := performAuthRequest("POST", "/user/totp/setup", nil, token)
+	var setup totpSetupResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	require.Equal(t, handler.SuccessCode, setup.Code)
+
+	w = performAuthRequest("POST", "/user/totp/enable", map[string]string{
+		"code": totpCode(t, setup.Data.Secret, 0),
+	}, token)
+	var enabled totpRecoveryCodesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enabled))
+	require.Equal(t, handler.SuccessCode, enabled.Code)
+	return setup.Data.Secret, enabled.Data.RecoveryCodes
+}
+
+// --- TOTP Tests ---
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test vector for SHA-1
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	code, err := totp.Code(secret, totp.Counter(time.Unix(59, 0)))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	uri := totp.URI("Collectify", "alice", secret)
+	assert.Contains(t, uri, "otpauth://totp/")
+	assert.Contains(t, uri, "secret="+secret)
+}
+
+func TestTOTPLogin(t *testing.T) {
+	enableAuth(t)
+	_, token := createTestUser(t, "totp-user", model.UserRoleAdmin, 0)
+
+	// 1. Setting up requires confirming a valid code
+	w := performAuthRequest("POST", "/user/totp/setup", nil, token)
+	var setup totpSetupResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	require.Equal(t, handler.SuccessCode, setup.Code)
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user/totp/enable", map[string]string{"code": "000000"}, token))
+	assert.Equal(t, handler.SuccessCode, login(t, "totp-user", "password").Code)
+
+	secret, recoveryCodes := enableTOTP(t, token)
+	assert.Len(t, recoveryCodes, 10)
+	var stored model.User
+	require.NoError(t, testDB.Where("username = ?", "totp-user").First(&stored).Error)
+	assert.NotContains(t, stored.TOTPRecoveryCodes, recoveryCodes[0])
+
+	// 2. The password alone only yields a challenge
+	challenge := loginChallenge(t, "/user/login", "totp-user", "password")
+	require.Equal(t, handler.SuccessCode, challenge.Code)
+	assert.True(t, challenge.Data.TOTPRequired)
+	assert.Empty(t, challenge.Data.Token)
+	assert.Equal(t, handler.FailCode, requestCode(t, "GET", "/user/tokens", nil, challenge.Data.Challenge))
+
+	// 3. Wrong and replayed codes are rejected
+	assert.Equal(t, handler.FailCode, loginTOTP(t, challenge.Data.Challenge, "000000").Code)
+	used, err := totp.Code(secret, stored.TOTPLastCounter)
+	require.NoError(t, err)
+	assert.Equal(t, handler.FailCode, loginTOTP(t, challenge.Data.Challenge, used).Code)
+
+	next, err := totp.Code(secret, stored.TOTPLastCounter+1)
+	require.NoError(t, err)
+	resp := loginTOTP(t, challenge.Data.Challenge, next)
+	require.Equal(t, handler.SuccessCode, resp.Code)
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "GET", "/user/tokens", nil, resp.Data.Token))
+	assert.Equal(t, handler.FailCode, loginTOTP(t, challenge.Data.Challenge, next).Code)
+
+	// 4. Recovery codes work once
+	assert.Equal(t, handler.SuccessCode, loginTOTP(t, challenge.Data.Challenge, recoveryCodes[0]).Code)
+	assert.Equal(t, handler.FailCode, loginTOTP(t, challenge.Data.Challenge, recoveryCodes[0]).Code)
+
+	// 5. Token pairs go through the same challenge
+	pairChallenge := loginChallenge(t, "/user/token", "totp-user", "password")
+	require.True(t, pairChallenge.Data.TOTPRequired)
+	w = performRequest("POST", "/user/login/totp", map[string]string{
+		"challenge": pairChallenge.Data.Challenge,
+		"code":      recoveryCodes[1],
+	})
+	var pair tokenPairResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pair))
+	require.Equal(t, handler.SuccessCode, pair.Code)
+	assert.NotEmpty(t, pair.Data.RefreshToken)
+}
+
+func TestTOTPDisableAndReset(t *testing.T) {
+	enableAuth(t)
+	_, adminToken := createTestUser(t, "totp-admin", model.UserRoleAdmin, 0)
+	user, token := createTestUser(t, "totp-lost", model.UserRoleEditor, 0)
+
+	// 1. Disabling requires the current password and a second factor
+	_, recoveryCodes := enableTOTP(t, token)
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", "/user/totp/disable", map[string]string{
+		"current_password": "wrong-password",
+		"code":             recoveryCodes[0],
+	}, token))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", "/user/totp/disable", map[string]string{
+		"current_password": "password",
+		"code":             recoveryCodes[0],
+	}, token))
+	assert.False(t, loginChallenge(t, "/user/login", "totp-lost", "password").Data.TOTPRequired)
+
+	// 2. Admins can reset it for users who lost their authenticator
+	enableTOTP(t, token)
+	assert.Equal(t, handler.FailCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/totp/reset", user.ID), nil, token))
+	assert.Equal(t, handler.SuccessCode, requestCode(t, "POST", fmt.Sprintf("/user/%d/totp/reset", user.ID), nil, adminToken))
+	assert.False(t, loginChallenge(t, "/user/login", "totp-lost", "password").Data.TOTPRequired)
+}
+
+func TestTOTPConcurrentRecoveryCode(t *testing.T) {
+	enableAuth(t)
+	_, token := createTestUser(t, "totp-racer", model.UserRoleAdmin, 0)
+	_, recoveryCodes := enableTOTP(t, token)
+
+	// 1. Concurrent logins with the same recovery code spend it only once
+	const attempts = 8
+	challenges := make([]string, attempts)
+	for i := range challenges {
+		challenge := loginChallenge(t, "/user/login", "totp-racer", "password")
+		require.True(t, challenge.Data.TOTPRequired)
+		challenges[i] = challenge.Data.Challenge
+	}
+
+	codes := make([]int, attempts)
+	for i := range codes {
+		codes[i] = handler.FailCode
+	}
+	var wg sync.WaitGroup
+	for i := range challenges {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := performRequest("POST", "/user/login/totp", map[string]string{
+				"challenge": challenges[i],
+				"code":      recoveryCodes[0],
+			})
+			var resp CommonResponse
+			if json.Unmarshal(w.Body.Bytes(), &resp) == nil {
+				codes[i] = resp.Code
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == handler.SuccessCode {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var stored model.User
+	require.NoError(t, testDB.Where("username = ?", "totp-racer").First(&stored).Error)
+	assert.Len(t, strings.Split(stored.TOTPRecoveryCodes, ","), len(recoveryCodes)-1)
+}
@@ -0,0 +1,114 @@
+package handler_test
+
+import (
+	"collectify/internal/config"
+	"collectify/internal/handler"
+	model "collectify/internal/model/db"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loginResponse struct {
+	CommonResponse
+	Data struct {
+		Token              string `json:"token"`
+		ID                 uint   `json:"id"`
+		Username           string `json:"username"`
+		MustChangePassword bool   `json:"must_change_password"`
+	} `json:"data"`
+}
+
+// enableAuth turns on authentication for the duration of a test.
+func enableAuth(t *testing.T) {
+	cfg := config.GetConfig()
+	old := cfg.Auth
+	cfg.Auth.Enable = true
+	cfg.Auth.JwtSecret = "test-secret"
+	t.Cleanup(func() {
+		cfg.Auth = old
+	})
+}
+
+func login(t *testing.T, username, password string) loginResponse {
+	w := performRequest("POST", "/user/login", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	var resp loginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// --- User Tests ---
+
+func TestUserPassword(t *testing.T) {
+	enableAuth(t)
+
+	// 1. The seeded admin is stored hashed and must change its password
+	var admin model.User
+	require.NoError(t, testDB.Where("username = ?", model.DefaultAdminUsername).First(&admin).Error)
+	assert.NotEqual(t, model.DefaultAdminPassword, admin.Password)
+	assert.True(t, admin.MustChangePassword)
+
+	loginResp := login(t, model.DefaultAdminUsername, model.DefaultAdminPassword)
+	require.Equal(t, handler.SuccessCode, loginResp.Code)
+	assert.True(t, loginResp.Data.MustChangePassword)
+	token := loginResp.Data.Token
+
+	// 2. Other routes are blocked until the password is changed
+	var resp CommonResponse
+	w := performAuthRequest("POST", "/tag", map[string]string{"name": "Blocked Tag"}, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	// 3. Changing the password requires the current one
+	update := map[string]interface{}{
+		"id":       admin.ID,
+		"username": model.DefaultAdminUsername,
+		"password": "new-secret",
+	}
+	w = performAuthRequest("POST", "/user/update", update, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	update["current_password"] = "wrong"
+	w = performAuthRequest("POST", "/user/update", update, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.FailCode, resp.Code)
+
+	update["current_password"] = model.DefaultAdminPassword
+	w = performAuthRequest("POST", "/user/update", update, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+
+	// 4. The account is usable and only the new password works
+	w = performAuthRequest("POST", "/tag", map[string]string{"name": "Unblocked Tag"}, token)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.SuccessCode, resp.Code)
+
+	assert.Equal(t, handler.FailCode, login(t, model.DefaultAdminUsername, model.DefaultAdminPassword).Code)
+	loginResp = login(t, model.DefaultAdminUsername, "new-secret")
+	assert.Equal(t, handler.SuccessCode, loginResp.Code)
+	assert.False(t, loginResp.Data.MustChangePassword)
+}
+
+func TestUserPlaintextPasswordUpgrade(t *testing.T) {
+	enableAuth(t)
+
+	legacy := model.User{Username: "legacy", Password: "plain-secret", Role: model.UserRoleAdmin}
+	require.NoError(t, testDB.Create(&legacy).Error)
+
+	loginResp := login(t, "legacy", "plain-secret")
+	assert.Equal(t, handler.SuccessCode, loginResp.Code)
+
+	var user model.User
+	require.NoError(t, testDB.First(&user, legacy.ID).Error)
+	assert.NotEqual(t, "plain-secret", user.Password)
+
+	// The upgraded hash keeps working
+	assert.Equal(t, handler.SuccessCode, login(t, "legacy", "plain-secret").Code)
+	assert.Equal(t, handler.FailCode, login(t, "legacy", "wrong").Code)
+}
@@ -5,7 +5,6 @@ import (
 	"collectify/internal/config"
 	"collectify/internal/conn"
 	"collectify/internal/router"
-	"collectify/internal/service"
 	"embed"
 	"log"
 )
@@ -22,14 +21,6 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// 如果回收站未启用，则清空回收站，避免唯一约束冲突
-	if !cfg.RecycleBin.Enable {
-		err = service.ClearRecycleBin()
-		if err != nil {
-			log.Fatalf("Failed to clear recycle bin: %v", err)
-		}
-	}
-
 	// 设置前端文件
 	router.SetFrontendFS(frontendEmbedFS)
 
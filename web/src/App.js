@@ -10,6 +10,7 @@ import TagListPage from './pages/TagListPage';
 import TagDetailPage from './pages/TagDetailPage';
 import CollectionListPage from './pages/CollectionListPage';
 import CollectionDetailPage from './pages/CollectionDetailPage';
+import OIDCCallbackPage from './pages/OIDCCallbackPage';
 // Import other pages as they are created
 // import SearchPage from './pages/SearchPage';
 
@@ -27,6 +28,7 @@ function App() {
         <Route path="/tags/:id" element={<TagDetailPage />} />
         <Route path="/collections" element={<CollectionListPage />} />
         <Route path="/collections/:id" element={<CollectionDetailPage />} />
+        <Route path="/oidc/callback" element={<OIDCCallbackPage />} />
         {/* <Route path="/search" element={<SearchPage />} /> */}
       </Routes>
     </div>
@@ -23,7 +23,8 @@ const AddItemDialog = ({ open, onClose, onItemAdded }) => {
     notes: '',
     cover_url: '',
     source_url: '',
-    priority: 0
+    priority: 0,
+    private: false
   });
   const [snackbar, setSnackbar] = useState({ open: false, message: '', severity: 'success' });
 
@@ -58,7 +59,8 @@ const AddItemDialog = ({ open, onClose, onItemAdded }) => {
             notes: '',
             cover_url: '',
             source_url: '',
-            priority: 0
+            priority: 0,
+            private: false
           });
           onItemAdded();
           onClose();
@@ -195,6 +197,17 @@ const AddItemDialog = ({ open, onClose, onItemAdded }) => {
             inputProps={{ min: 0 }}
             disabled={isCreating}
           />
+
+          <FormControlLabel
+            control={
+              <Checkbox
+                checked={itemData.private}
+                onChange={(e) => handleInputChange('private', e.target.checked)}
+                disabled={isCreating}
+              />
+            }
+            label="Private (hidden from visitors who are not logged in)"
+          />
         </Box>
       </DialogContent>
       <DialogActions>
@@ -1,4 +1,4 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect } from 'react';
 import {
   Dialog, DialogTitle, DialogContent, DialogActions,
   TextField, Button, Box, CircularProgress, Alert
@@ -12,8 +12,40 @@ const LoginDialog = ({ open, onClose }) => {
   const [password, setPassword] = useState('');
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
+  const [challenge, setChallenge] = useState('');
+  const [code, setCode] = useState('');
+  const [oidcEnabled, setOIDCEnabled] = useState(false);
+
+  useEffect(() => {
+    if (!open) return;
+    authService.isOIDCEnabled()
+      .then((response) => setOIDCEnabled(response.data))
+      .catch(() => setOIDCEnabled(false));
+  }, [open]);
+
+  const completeLogin = (response) => {
+    login(
+      {
+        id: response.data.id,
+        username: response.data.username,
+        role: response.data.role,
+        must_change_password: response.data.must_change_password
+      },
+      response.data.token
+    );
+    onClose();
+    // Reset form
+    setUsername('');
+    setPassword('');
+    setChallenge('');
+    setCode('');
+  };
 
   const handleLogin = async () => {
+    if (challenge) {
+      handleVerify();
+      return;
+    }
     if (!username.trim() || !password.trim()) {
       setError('Please enter both username and password');
       return;
@@ -24,18 +56,12 @@ const LoginDialog = ({ open, onClose }) => {
 
     try {
       const response = await authService.login(username, password);
-      login(
-        {
-          id: response.data.id,
-          username: response.data.username,
-          role: response.data.role
-        },
-        response.data.token
-      );
-      onClose();
-      // Reset form
-      setUsername('');
-      setPassword('');
+      // 启用两步验证时需输入验证码
+      if (response.data.totp_required) {
+        setChallenge(response.data.challenge);
+        return;
+      }
+      completeLogin(response);
     } catch (err) {
       setError(err.message);
     } finally {
@@ -43,6 +69,40 @@ const LoginDialog = ({ open, onClose }) => {
     }
   };
 
+  const handleVerify = async () => {
+    if (!code.trim()) {
+      setError('Please enter the authentication code');
+      return;
+    }
+
+    setLoading(true);
+    setError('');
+
+    try {
+      const response = await authService.loginTOTP(challenge, code.trim());
+      completeLogin(response);
+    } catch (err) {
+      setError(err.message);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // 跳转到身份提供方登录，回调页面需比对 state
+  const handleOIDCLogin = async () => {
+    setLoading(true);
+    setError('');
+
+    try {
+      const response = await authService.oidcLogin();
+      sessionStorage.setItem('oidc_state', response.data.state);
+      window.location.assign(response.data.url);
+    } catch (err) {
+      setError(err.message);
+      setLoading(false);
+    }
+  };
+
   const handleKeyPress = (e) => {
     if (e.key === 'Enter') {
       handleLogin();
@@ -65,7 +125,7 @@ const LoginDialog = ({ open, onClose }) => {
             value={username}
             onChange={(e) => setUsername(e.target.value)}
             onKeyPress={handleKeyPress}
-            disabled={loading}
+            disabled={loading || !!challenge}
           />
           <TextField
             margin="dense"
@@ -76,21 +136,40 @@ const LoginDialog = ({ open, onClose }) => {
             value={password}
             onChange={(e) => setPassword(e.target.value)}
             onKeyPress={handleKeyPress}
-            disabled={loading}
+            disabled={loading || !!challenge}
           />
+          {challenge && (
+            <TextField
+              autoFocus
+              margin="dense"
+              label="Authentication code or recovery code"
+              type="text"
+              fullWidth
+              variant="outlined"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              onKeyPress={handleKeyPress}
+              disabled={loading}
+            />
+          )}
         </Box>
       </DialogContent>
       <DialogActions>
+        {oidcEnabled && !challenge && (
+          <Button onClick={handleOIDCLogin} disabled={loading} sx={{ mr: 'auto' }}>
+            Sign in with SSO
+          </Button>
+        )}
         <Button onClick={onClose} disabled={loading}>
           Cancel
         </Button>
         <Button 
           onClick={handleLogin} 
-          disabled={loading || !username.trim() || !password.trim()}
+          disabled={loading || !username.trim() || !password.trim() || (!!challenge && !code.trim())}
           variant="contained"
           color="primary"
         >
-          {loading ? <CircularProgress size={24} /> : 'Login'}
+          {loading ? <CircularProgress size={24} /> : (challenge ? 'Verify' : 'Login')}
         </Button>
       </DialogActions>
     </Dialog>
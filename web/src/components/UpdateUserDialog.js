@@ -9,6 +9,7 @@ import { authService } from '../services/authService';
 const UpdateUserDialog = ({ open, onClose }) => {
   const { user, login, token } = useAuth();
   const [username, setUsername] = useState(user?.username || '');
+  const [currentPassword, setCurrentPassword] = useState('');
   const [password, setPassword] = useState('');
   const [confirmPassword, setConfirmPassword] = useState('');
   const [loading, setLoading] = useState(false);
@@ -21,6 +22,11 @@ const UpdateUserDialog = ({ open, onClose }) => {
       return;
     }
     
+    if (!currentPassword) {
+      setError('Current password is required');
+      return;
+    }
+
     if (password && password !== confirmPassword) {
       setError('Passwords do not match');
       return;
@@ -33,7 +39,8 @@ const UpdateUserDialog = ({ open, onClose }) => {
       const userData = {
         id: user.id,
         username: username,
-        password: password || user.password
+        password: password,
+        current_password: currentPassword
       };
       
       await authService.updateUser(userData);
@@ -41,7 +48,8 @@ const UpdateUserDialog = ({ open, onClose }) => {
       // 更新本地存储的用户信息
       const updatedUser = {
         ...user,
-        username: username
+        username: username,
+        must_change_password: user.must_change_password && !password
       };
       
       login(updatedUser, token);
@@ -52,6 +60,7 @@ const UpdateUserDialog = ({ open, onClose }) => {
       
       // Reset form
       setUsername(username);
+      setCurrentPassword('');
       setPassword('');
       setConfirmPassword('');
     } catch (err) {
@@ -89,7 +98,17 @@ const UpdateUserDialog = ({ open, onClose }) => {
             />
             <TextField
               margin="dense"
-              label="New Password (optional)"
+              label="Current Password"
+              type="password"
+              fullWidth
+              variant="outlined"
+              value={currentPassword}
+              onChange={(e) => setCurrentPassword(e.target.value)}
+              disabled={loading}
+            />
+            <TextField
+              margin="dense"
+              label={user?.must_change_password ? 'New Password' : 'New Password (optional)'}
               type="password"
               fullWidth
               variant="outlined"
@@ -115,7 +134,7 @@ const UpdateUserDialog = ({ open, onClose }) => {
           </Button>
           <Button 
             onClick={handleUpdate} 
-            disabled={loading || !username.trim()}
+            disabled={loading || !username.trim() || !currentPassword}
             variant="contained"
             color="primary"
           >
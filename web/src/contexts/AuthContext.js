@@ -64,6 +64,11 @@ export const AuthProvider = ({ children }) => {
   };
 
   const logout = () => {
+    // 吊销服务端会话，失败时不影响本地退出
+    const storedToken = localStorage.getItem('token');
+    if (storedToken) {
+      authService.logout(storedToken).catch(() => {});
+    }
     setUser(null);
     setToken(null);
     localStorage.removeItem('token');
@@ -25,4 +25,15 @@ export const useDeleteField = () => {
       queryClient.invalidateQueries({ queryKey: ['category'] });
     },
   });
-};
\ No newline at end of file
+};
+// Custom hook for updating the options of a select field
+export const useUpdateFieldOptions = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, options }) => fieldService.updateOptions(id, options),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['category'] });
+      queryClient.invalidateQueries({ queryKey: ['item'] });
+    },
+  });
+};
@@ -1,8 +1,8 @@
 // src/pages/CategoryDetailPage.js
 import React, { useState } from 'react';
 import { useNavigate, useParams } from 'react-router-dom';
-import { useCategory, useRenameCategory, useDeleteCategory } from '../hooks/useCategories';
-import { useCreateField, useDeleteField } from '../hooks/useFields';
+import { useCategories, useCategory, useRenameCategory, useDeleteCategory } from '../hooks/useCategories';
+import { useCreateField, useDeleteField, useUpdateFieldOptions } from '../hooks/useFields';
 import { useSearchItems } from '../hooks/useItems';
 import ItemList from '../components/ItemList';
 import { getFieldTypeName } from '../utils/itemUtils';
@@ -10,7 +10,7 @@ import {
   Container, Typography, Box, CircularProgress, Alert, Button, Dialog, DialogTitle,
   DialogContent, DialogActions, TextField, FormControl, InputLabel, Select, MenuItem,
   Table, TableBody, TableCell, TableContainer, TableHead, TableRow, Paper, IconButton,
-  Snackbar
+  Snackbar, Chip
 } from '@mui/material';
 import { Edit, Delete, Add } from '@mui/icons-material';
 
@@ -20,14 +20,19 @@ const CategoryDetailPage = () => {
   const categoryId = parseInt(id, 10);
 
   const { data: categoryData, isLoading: isCategoryLoading, error: categoryError } = useCategory(categoryId);
+  const { data: categoriesData } = useCategories(); // Target categories for reference fields
   const category = categoryData?.data;
   
   const { mutate: renameCategory, error: renameError } = useRenameCategory();
   const { mutate: deleteCategory, error: deleteCategoryError } = useDeleteCategory();
   const { mutate: createField, error: createFieldError } = useCreateField();
+  const { mutate: updateFieldOptions } = useUpdateFieldOptions();
   const { mutate: deleteField, error: deleteFieldError } = useDeleteField();
 
-  const defaultSearchParams = { category_id: categoryId, page: 1, page_size: 10 };
+  const [sort, setSort] = useState('');
+  const [queryInput, setQueryInput] = useState('');
+  const [q, setQ] = useState(''); // 按回车后才执行查询语句
+  const defaultSearchParams = { category_id: categoryId, page: 1, page_size: 10, sort, q };
   const { data: itemsData, isLoading: isItemsLoading, error: itemsError, refetch: refetchItems } = useSearchItems(defaultSearchParams);
   const items = itemsData?.data?.list || [];
   const totalItems = itemsData?.data?.total || 0;
@@ -35,7 +40,8 @@ const CategoryDetailPage = () => {
   const [editName, setEditName] = useState(false);
   const [newName, setNewName] = useState(category?.name || '');
   const [openFieldDialog, setOpenFieldDialog] = useState(false);
-  const [newField, setNewField] = useState({ name: '', type: 1, is_array: false, required: false });
+  const [newField, setNewField] = useState({ name: '', type: 1, is_array: false, required: false, options: '', unit: '', precision: '' });
+  const [editingOptions, setEditingOptions] = useState(null); // { fieldId, options: [{ id, value, color }] }
   const [openDeleteDialog, setOpenDeleteDialog] = useState(false);
   const [snackbar, setSnackbar] = useState({ open: false, message: '', severity: 'success' });
 
@@ -80,23 +86,26 @@ const CategoryDetailPage = () => {
   };
 
   const handleCreateField = () => {
-    // Prevent creating boolean array fields
-    if (newField.type === 3 && newField.is_array) {
-      setSnackbar({ open: true, message: 'Boolean type fields cannot be arrays.', severity: 'warning' });
+    // 选择字段的选项以逗号分隔输入
+    const options = newField.type === 5
+      ? newField.options.split(',').map(v => v.trim()).filter(Boolean).map(value => ({ value }))
+      : [];
+    if (newField.type === 5 && options.length === 0) {
+      setSnackbar({ open: true, message: 'Select fields need at least one option.', severity: 'warning' });
       return;
     }
-    
-    // Prevent creating datetime array fields
-    if (newField.type === 4 && newField.is_array) {
-      setSnackbar({ open: true, message: 'Datetime type fields cannot be arrays.', severity: 'warning' });
-      return;
-    }
-    
+
     if (newField.name.trim()) {
-      createField({ ...newField, category_id: categoryId }, {
+      // 单位和精度仅对小数字段有效
+      const decimal = newField.type === 6
+        ? { unit: newField.unit.trim(), precision: newField.precision === '' ? undefined : Number(newField.precision) }
+        : { unit: '', precision: undefined };
+      // 关联字段可限定目标分类
+      const target_category_id = newField.type === 7 ? newField.target_category_id : undefined;
+      createField({ ...newField, ...decimal, options, target_category_id, category_id: categoryId }, {
         onSuccess: () => {
           setOpenFieldDialog(false);
-          setNewField({ name: '', type: 1, is_array: false, required: false });
+          setNewField({ name: '', type: 1, is_array: false, required: false, options: '', unit: '', precision: '' });
           setSnackbar({ open: true, message: 'Field created successfully!', severity: 'success' });
         },
         onError: (error) => {
@@ -107,6 +116,28 @@ const CategoryDetailPage = () => {
     }
   };
 
+  const handleSaveOptions = () => {
+    const options = editingOptions.options
+      .filter(option => option.value.trim())
+      .map(option => ({ ...option, value: option.value.trim() }));
+    updateFieldOptions({ id: editingOptions.fieldId, options }, {
+      onSuccess: () => {
+        setEditingOptions(null);
+        setSnackbar({ open: true, message: 'Options updated successfully!', severity: 'success' });
+      },
+      onError: (error) => {
+        setSnackbar({ open: true, message: `Error: ${error.message}`, severity: 'error' });
+      }
+    });
+  };
+
+  const updateEditingOption = (index, changes) => {
+    setEditingOptions(prev => ({
+      ...prev,
+      options: prev.options.map((option, i) => (i === index ? { ...option, ...changes } : option)),
+    }));
+  };
+
   const handleDeleteField = (fieldId) => {
     // Simple confirmation, could be improved with a proper dialog
     if (window.confirm('Are you sure you want to delete this field?')) {
@@ -214,10 +245,37 @@ const CategoryDetailPage = () => {
                 {category.fields.map((field) => (
                   <TableRow key={field.id}>
                     <TableCell>{field.name}</TableCell>
-                    <TableCell>{getFieldTypeName(field.type)}</TableCell>
+                    <TableCell>
+                      {getFieldTypeName(field.type)}
+                      {field.unit && ` (${field.unit})`}
+                      {field.options && field.options.length > 0 && (
+                        <Box mt={0.5}>
+                          {field.options.map(option => (
+                            <Chip
+                              key={option.id}
+                              label={option.value}
+                              size="small"
+                              sx={{ mr: 0.5, mb: 0.5, ...(option.color && { bgcolor: option.color, color: '#fff' }) }}
+                            />
+                          ))}
+                        </Box>
+                      )}
+                    </TableCell>
                     <TableCell>{field.is_array ? 'Yes' : 'No'}</TableCell>
                     <TableCell>{field.required ? 'Yes' : 'No'}</TableCell>
                     <TableCell>
+                      {field.type === 5 && (
+                        <IconButton
+                          aria-label="edit options"
+                          size="small"
+                          onClick={() => setEditingOptions({
+                            fieldId: field.id,
+                            options: (field.options || []).map(({ id, value, color }) => ({ id, value, color: color || '' })),
+                          })}
+                        >
+                          <Edit />
+                        </IconButton>
+                      )}
                       <IconButton
                         aria-label="delete"
                         size="small"
@@ -246,9 +304,36 @@ const CategoryDetailPage = () => {
           </Button>
         </Box>
 
-        <Typography variant="h6" gutterBottom mt={4}>
-          Items in this Category
-        </Typography>
+        <Box display="flex" justifyContent="space-between" alignItems="center" mt={4} mb={1}>
+          <Typography variant="h6">
+            Items in this Category
+          </Typography>
+          <Box flexGrow={1} mx={2}>
+            <TextField
+              size="small"
+              fullWidth
+              placeholder='Query, e.g. rating>=8 AND NOT status:abandoned AND author:"Le Guin"'
+              value={queryInput}
+              onChange={(e) => setQueryInput(e.target.value)}
+              onKeyDown={(e) => e.key === 'Enter' && setQ(queryInput.trim())}
+            />
+          </Box>
+          <FormControl size="small" sx={{ minWidth: 200 }}>
+            <InputLabel>Sort By</InputLabel>
+            <Select value={sort} label="Sort By" onChange={(e) => setSort(e.target.value)}>
+              <MenuItem value="">Recently Updated</MenuItem>
+              <MenuItem value="name">Name</MenuItem>
+              <MenuItem value="-rating">Rating</MenuItem>
+              <MenuItem value="-priority">Priority</MenuItem>
+              <MenuItem value="-created_at">Recently Created</MenuItem>
+              <MenuItem value="-completed_at">Recently Completed</MenuItem>
+              {(category.fields || []).flatMap(field => [
+                <MenuItem key={`field:${field.id}`} value={`field:${field.id}`}>{field.name} ↑</MenuItem>,
+                <MenuItem key={`-field:${field.id}`} value={`-field:${field.id}`}>{field.name} ↓</MenuItem>,
+              ])}
+            </Select>
+          </FormControl>
+        </Box>
         <ItemList
           items={items}
           total={totalItems}
@@ -286,13 +371,64 @@ const CategoryDetailPage = () => {
               <MenuItem value={2}>Integer</MenuItem>
               <MenuItem value={3}>Boolean</MenuItem>
               <MenuItem value={4}>Datetime</MenuItem>
+              <MenuItem value={5}>Select</MenuItem>
+              <MenuItem value={6}>Decimal</MenuItem>
+              <MenuItem value={7}>Reference</MenuItem>
             </Select>
           </FormControl>
+          {newField.type === 7 && (
+            <FormControl fullWidth margin="dense">
+              <InputLabel>Target Category (optional)</InputLabel>
+              <Select
+                value={newField.target_category_id || ''}
+                label="Target Category (optional)"
+                onChange={(e) => setNewField({ ...newField, target_category_id: e.target.value || undefined })}
+              >
+                <MenuItem value="">Any category</MenuItem>
+                {(categoriesData?.data?.list || []).map(c => (
+                  <MenuItem key={c.id} value={c.id}>{c.name}</MenuItem>
+                ))}
+              </Select>
+            </FormControl>
+          )}
+          {newField.type === 6 && (
+            <Box display="flex" gap={2}>
+              <TextField
+                margin="dense"
+                label="Unit (optional)"
+                type="text"
+                variant="outlined"
+                value={newField.unit}
+                onChange={(e) => setNewField({ ...newField, unit: e.target.value })}
+                placeholder="kg"
+              />
+              <TextField
+                margin="dense"
+                label="Decimal places (optional)"
+                type="number"
+                variant="outlined"
+                value={newField.precision}
+                onChange={(e) => setNewField({ ...newField, precision: e.target.value })}
+                inputProps={{ min: 0, max: 10 }}
+              />
+            </Box>
+          )}
+          {newField.type === 5 && (
+            <TextField
+              margin="dense"
+              label="Options (comma separated)"
+              type="text"
+              fullWidth
+              variant="outlined"
+              value={newField.options}
+              onChange={(e) => setNewField({ ...newField, options: e.target.value })}
+              helperText="Enable Array to allow selecting multiple options"
+            />
+          )}
           <Box display="flex" justifyContent="space-between" mt={2}>
             <Button
               variant={newField.is_array ? "contained" : "outlined"}
               onClick={() => setNewField({ ...newField, is_array: !newField.is_array })}
-              disabled={newField.type === 3 || newField.type === 4} // Disable array for Boolean and Datetime types
             >
               Array
             </Button>
@@ -303,16 +439,6 @@ const CategoryDetailPage = () => {
               Required
             </Button>
           </Box>
-          {newField.type === 3 && newField.is_array && (
-            <Alert severity="warning" sx={{ mt: 2 }}>
-              Boolean type cannot be an array. Array option has been disabled.
-            </Alert>
-          )}
-          {newField.type === 4 && newField.is_array && (
-            <Alert severity="warning" sx={{ mt: 2 }}>
-              Datetime type cannot be an array. Array option has been disabled.
-            </Alert>
-          )}
         </DialogContent>
         <DialogActions>
           <Button onClick={() => setOpenFieldDialog(false)}>Cancel</Button>
@@ -320,6 +446,60 @@ const CategoryDetailPage = () => {
         </DialogActions>
       </Dialog>
       
+      {/* Edit Select Options Dialog */}
+      <Dialog open={!!editingOptions} onClose={() => setEditingOptions(null)}>
+        <DialogTitle>Edit Options</DialogTitle>
+        <DialogContent>
+          <Typography variant="body2" color="textSecondary" gutterBottom>
+            Renaming an option keeps the values of existing items. Options in use cannot be removed.
+          </Typography>
+          {editingOptions?.options.map((option, index) => (
+            <Box key={option.id || `new-${index}`} display="flex" alignItems="center" gap={1}>
+              <TextField
+                margin="dense"
+                label="Option"
+                size="small"
+                value={option.value}
+                onChange={(e) => updateEditingOption(index, { value: e.target.value })}
+              />
+              <TextField
+                margin="dense"
+                label="Color"
+                size="small"
+                placeholder="#1976d2"
+                value={option.color}
+                onChange={(e) => updateEditingOption(index, { color: e.target.value })}
+                sx={{ width: 120 }}
+              />
+              <IconButton
+                aria-label="remove option"
+                size="small"
+                onClick={() => setEditingOptions(prev => ({
+                  ...prev,
+                  options: prev.options.filter((_, i) => i !== index),
+                }))}
+              >
+                <Delete />
+              </IconButton>
+            </Box>
+          ))}
+          <Button
+            startIcon={<Add />}
+            onClick={() => setEditingOptions(prev => ({
+              ...prev,
+              options: [...prev.options, { value: '', color: '' }],
+            }))}
+            sx={{ mt: 1 }}
+          >
+            Add Option
+          </Button>
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setEditingOptions(null)}>Cancel</Button>
+          <Button onClick={handleSaveOptions}>Save</Button>
+        </DialogActions>
+      </Dialog>
+
       {/* Delete Confirmation Dialog */}
       <Dialog
         open={openDeleteDialog}
@@ -1,7 +1,7 @@
 // src/pages/ItemDetailPage.js
 import React, { useState } from 'react';
-import { useParams } from 'react-router-dom';
-import { useItem, useUpdateItem, useDeleteItem } from '../hooks/useItems';
+import { Link, useParams } from 'react-router-dom';
+import { useItem, useUpdateItem, useDeleteItem, useSearchItems } from '../hooks/useItems';
 import { useTags } from '../hooks/useTags'; // For getting available tags
 import { useCollections } from '../hooks/useCollections'; // For getting available collections
 import { useAddTagToItem, useRemoveTagFromItem, useAddItemToCollection, useRemoveItemFromCollection } from '../hooks/useItemAssociations';
@@ -11,7 +11,7 @@ import {
   Container, Typography, Box, CircularProgress, Alert, Button, Dialog, DialogTitle,
   DialogContent, DialogActions, TextField, FormControl, InputLabel, Select, MenuItem,
   FormControlLabel, Checkbox, Paper, Divider, IconButton, Snackbar, Alert as MuiAlert,
-  Chip, Autocomplete
+  Chip, Autocomplete, InputAdornment
 } from '@mui/material';
 import { Edit, Delete, Save, Cancel, Label, CollectionsBookmark } from '@mui/icons-material';
 
@@ -36,6 +36,9 @@ const ItemDetailPage = () => {
   const { data: collectionsData } = useCollections();
   const availableTags = tagsData?.data?.list || [];
   const availableCollections = collectionsData?.data?.list || [];
+  // Candidate items for reference fields
+  const { data: referenceItemsData } = useSearchItems({ no_paging: true });
+  const referenceItems = referenceItemsData?.data?.list || [];
 
   const [editMode, setEditMode] = useState(false);
   const [editedItem, setEditedItem] = useState({});
@@ -56,6 +59,9 @@ const ItemDetailPage = () => {
         cover_url: item.cover_url || '',
         source_url: item.source_url || '',
         priority: item.priority || 0,
+        private: !!item.private,
+        // 每行一个别名
+        aliases: (item.aliases || []).map(a => a.name).join('\n'),
         // Values for custom fields will be handled separately
         values: item.values ? [...item.values] : []
       };
@@ -67,6 +73,10 @@ const ItemDetailPage = () => {
     setEditedItem(prev => ({ ...prev, [field]: value }));
   };
 
+  // 选择字段的选项和是否多选来自分类的字段定义
+  const getCategoryField = (fieldId) =>
+    item?.category?.fields?.find(f => f.id === fieldId);
+
   const handleValueChange = (fieldId, newValue) => {
     setEditedItem(prev => {
       const newValues = [...(prev.values || [])];
@@ -93,7 +103,16 @@ const ItemDetailPage = () => {
         cover_url: editedItem.cover_url,
         source_url: editedItem.source_url,
         priority: parseInt(editedItem.priority, 10),
-        values: editedItem.values // This includes the custom field values
+        private: editedItem.private,
+        // 保留已有别名的类型，新增的别名类型为 other
+        aliases: (editedItem.aliases || '').split('\n').map(name => name.trim()).filter(Boolean).map(name => ({
+          name,
+          type: item.aliases?.find(a => a.name === name)?.type || 'other'
+        })),
+        // Reference fields are sent as item IDs
+        values: (editedItem.values || []).map(v => (v.field_type === 7
+          ? { ...v, value: Array.isArray(v.value) ? v.value.filter(Boolean).map(r => r.id) : v.value?.id }
+          : v))
       },
       category_id: item.category_id // Assuming category_id is not editable here
     };
@@ -288,6 +307,16 @@ const ItemDetailPage = () => {
               onChange={(e) => handleInputChange('rating', e.target.value)}
               inputProps={{ min: 0, max: 10, step: 0.1 }}
             />
+            <TextField
+              label="Aliases"
+              helperText="One per line, e.g. original or translated titles"
+              fullWidth
+              margin="normal"
+              multiline
+              minRows={2}
+              value={editedItem.aliases || ''}
+              onChange={(e) => handleInputChange('aliases', e.target.value)}
+            />
             <TextField
               label="Description"
               fullWidth
@@ -329,6 +358,15 @@ const ItemDetailPage = () => {
               onChange={(e) => handleInputChange('priority', e.target.value)}
               inputProps={{ min: 0 }}
             />
+            <FormControlLabel
+              control={
+                <Checkbox
+                  checked={!!editedItem.private}
+                  onChange={(e) => handleInputChange('private', e.target.checked)}
+                />
+              }
+              label="Private (hidden from visitors who are not logged in)"
+            />
 
             <Divider sx={{ my: 2 }} />
             <Typography variant="h6" gutterBottom>
@@ -357,6 +395,36 @@ const ItemDetailPage = () => {
                         onChange={(e) => handleValueChange(field.field_id, parseInt(e.target.value, 10))}
                       />
                     )}
+                    {field.field_type === 6 && !Array.isArray(fieldValue.value) && ( // Decimal
+                      <TextField
+                        fullWidth
+                        type="number"
+                        variant="outlined"
+                        value={fieldValue.value ?? ''}
+                        onChange={(e) => handleValueChange(field.field_id, e.target.value === '' ? null : parseFloat(e.target.value))}
+                        inputProps={{ step: 'any' }}
+                        InputProps={field.field_unit ? { endAdornment: <InputAdornment position="end">{field.field_unit}</InputAdornment> } : undefined}
+                      />
+                    )}
+                    {field.field_type === 7 && ( // Reference
+                      <Autocomplete
+                        multiple={!!getCategoryField(field.field_id)?.is_array}
+                        options={referenceItems
+                          .filter(candidate => candidate.id !== item.id)
+                          .filter(candidate => {
+                            const target = getCategoryField(field.field_id)?.target_category_id;
+                            return !target || candidate.category?.id === target;
+                          })
+                          .map(candidate => ({ id: candidate.id, name: candidate.name }))}
+                        getOptionLabel={(option) => option?.name || ''}
+                        isOptionEqualToValue={(option, value) => option.id === value.id}
+                        value={getCategoryField(field.field_id)?.is_array
+                          ? (fieldValue.value || []).filter(Boolean)
+                          : (fieldValue.value || null)}
+                        onChange={(e, newValue) => handleValueChange(field.field_id, newValue)}
+                        renderInput={(params) => <TextField {...params} variant="outlined" />}
+                      />
+                    )}
                     {field.field_type === 3 && ( // Boolean
                       <FormControlLabel
                         control={
@@ -380,6 +448,21 @@ const ItemDetailPage = () => {
                         }}
                       />
                     )}
+                    {field.field_type === 5 && ( // Select
+                      <FormControl fullWidth variant="outlined">
+                        <Select
+                          multiple={!!getCategoryField(field.field_id)?.is_array}
+                          value={fieldValue.value ?? (getCategoryField(field.field_id)?.is_array ? [] : '')}
+                          onChange={(e) => handleValueChange(field.field_id, e.target.value)}
+                        >
+                          {(getCategoryField(field.field_id)?.options || []).map((option) => (
+                            <MenuItem key={option.id} value={option.value}>
+                              {option.value}
+                            </MenuItem>
+                          ))}
+                        </Select>
+                      </FormControl>
+                    )}
                     {/* Add handling for array types if needed */}
                   </Box>
                 );
@@ -414,11 +497,15 @@ const ItemDetailPage = () => {
         ) : (
           <Paper elevation={3} sx={{ p: 3 }}>
             <Typography variant="h6" gutterBottom>Details</Typography>
+            {item.aliases?.length > 0 && (
+              <Typography><strong>Aliases:</strong> {item.aliases.map(a => a.name).join(', ')}</Typography>
+            )}
             <Typography><strong>Status:</strong> {getStatusText(item.status)}</Typography>
             <Typography><strong>Rating:</strong> {item.rating !== null ? item.rating : 'N/A'}</Typography>
             <Typography><strong>Description:</strong> {item.description || 'N/A'}</Typography>
             <Typography><strong>Notes:</strong> {item.notes || 'N/A'}</Typography>
             <Typography><strong>Priority:</strong> {item.priority}</Typography>
+            <Typography><strong>Private:</strong> {item.private ? 'Yes' : 'No'}</Typography>
             <Typography><strong>Created:</strong> {formatDate(item.created_at)}</Typography>
             <Typography><strong>Updated:</strong> {formatDate(item.updated_at)}</Typography>
             {item.completed_at && <Typography><strong>Completed:</strong> {formatDate(item.completed_at)}</Typography>}
@@ -503,7 +590,14 @@ const ItemDetailPage = () => {
                       {fieldValue.field_name}:
                     </Typography>
                     <Typography component="dd" variant="body1" sx={{ ml: 1 }}>
-                      {formatFieldValue(fieldValue.value, fieldValue.field_type)}
+                      {fieldValue.field_type === 7
+                        ? [].concat(fieldValue.value || []).filter(Boolean).map((ref, idx) => (
+                          <React.Fragment key={ref.id}>
+                            {idx > 0 && ', '}
+                            <Link to={`/items/${ref.id}`}>{ref.name}</Link>
+                          </React.Fragment>
+                        ))
+                        : formatFieldValue(fieldValue.value, fieldValue.field_type, fieldValue.field_unit)}
                     </Typography>
                   </Box>
                 ))}
@@ -513,6 +607,19 @@ const ItemDetailPage = () => {
                 No custom fields for this item.
               </Typography>
             )}
+
+            {item.referenced_by && item.referenced_by.length > 0 && (
+              <>
+                <Divider sx={{ my: 2 }} />
+                <Typography variant="h6" gutterBottom>Referenced By</Typography>
+                {item.referenced_by.map((ref) => (
+                  <Typography key={`${ref.item_id}-${ref.field_id}`} variant="body1">
+                    <Link to={`/items/${ref.item_id}`}>{ref.item_name}</Link>
+                    <Typography component="span" variant="body2" color="textSecondary"> ({ref.field_name})</Typography>
+                  </Typography>
+                ))}
+              </>
+            )}
           </Paper>
         )}
       </Box>
@@ -0,0 +1,116 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import {
+  Container, Box, TextField, Button, CircularProgress, Alert, Typography
+} from '@mui/material';
+import { useAuth } from '../contexts/AuthContext';
+import { authService } from '../services/authService';
+
+// 身份提供方登录完成后的回调页面
+const OIDCCallbackPage = () => {
+  const { login } = useAuth();
+  const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+  const [error, setError] = useState('');
+  const [challenge, setChallenge] = useState('');
+  const [code, setCode] = useState('');
+  const [loading, setLoading] = useState(false);
+  const handled = useRef(false);
+
+  const completeLogin = (response) => {
+    login(
+      {
+        id: response.data.id,
+        username: response.data.username,
+        role: response.data.role,
+        must_change_password: response.data.must_change_password
+      },
+      response.data.token
+    );
+    navigate('/', { replace: true });
+  };
+
+  useEffect(() => {
+    // 授权码只能使用一次，避免重复提交
+    if (handled.current) return;
+    handled.current = true;
+
+    const state = searchParams.get('state');
+    const expectedState = sessionStorage.getItem('oidc_state');
+    sessionStorage.removeItem('oidc_state');
+
+    if (searchParams.get('error')) {
+      setError(searchParams.get('error_description') || searchParams.get('error'));
+      return;
+    }
+    if (!state || state !== expectedState) {
+      setError('Invalid login state, please try again');
+      return;
+    }
+
+    setLoading(true);
+    authService.oidcCallback(searchParams.get('code'), state)
+      .then((response) => {
+        // 启用两步验证时需输入验证码
+        if (response.data.totp_required) {
+          setChallenge(response.data.challenge);
+          return;
+        }
+        completeLogin(response);
+      })
+      .catch((err) => setError(err.message))
+      .finally(() => setLoading(false));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const handleVerify = async () => {
+    setLoading(true);
+    setError('');
+
+    try {
+      const response = await authService.loginTOTP(challenge, code.trim());
+      completeLogin(response);
+    } catch (err) {
+      setError(err.message);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <Container maxWidth="xs">
+      <Box mt={4}>
+        <Typography variant="h5" gutterBottom>Login</Typography>
+        {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
+        {loading && !challenge && <CircularProgress />}
+        {challenge && (
+          <>
+            <TextField
+              autoFocus
+              margin="dense"
+              label="Authentication code or recovery code"
+              type="text"
+              fullWidth
+              variant="outlined"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              onKeyPress={(e) => e.key === 'Enter' && code.trim() && handleVerify()}
+              disabled={loading}
+            />
+            <Button
+              onClick={handleVerify}
+              disabled={loading || !code.trim()}
+              variant="contained"
+              color="primary"
+              sx={{ mt: 2 }}
+            >
+              {loading ? <CircularProgress size={24} /> : 'Verify'}
+            </Button>
+          </>
+        )}
+      </Box>
+    </Container>
+  );
+};
+
+export default OIDCCallbackPage;
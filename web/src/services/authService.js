@@ -10,6 +10,59 @@ export const authService = {
     }
   },
   
+  // 使用验证码或恢复码完成两步验证
+  loginTOTP: async (challenge, code) => {
+    try {
+      const response = await apiClient.post('/user/login/totp', { challenge, code });
+      return response;
+    } catch (error) {
+      throw new Error(`Login failed: ${error.message}`);
+    }
+  },
+
+  // 检查是否启用了 OpenID Connect 登录
+  isOIDCEnabled: async () => {
+    try {
+      const response = await apiClient.get('/user/oidc/enabled');
+      return response;
+    } catch (error) {
+      throw new Error(`Failed to check OIDC status: ${error.message}`);
+    }
+  },
+
+  // 获取身份提供方的登录地址
+  oidcLogin: async () => {
+    try {
+      const response = await apiClient.get('/user/oidc/login');
+      return response;
+    } catch (error) {
+      throw new Error(`Login failed: ${error.message}`);
+    }
+  },
+
+  // 使用身份提供方回调的授权码登录
+  oidcCallback: async (code, state) => {
+    try {
+      const response = await apiClient.post('/user/oidc/callback', { code, state });
+      return response;
+    } catch (error) {
+      throw new Error(`Login failed: ${error.message}`);
+    }
+  },
+
+  // 退出登录，吊销当前会话
+  // 显式携带 token，避免本地登录状态先被清除
+  logout: async (token) => {
+    try {
+      const response = await apiClient.post('/user/logout', null, {
+        headers: { Authorization: token }
+      });
+      return response;
+    } catch (error) {
+      throw new Error(`Logout failed: ${error.message}`);
+    }
+  },
+
   // 检查认证是否启用
   isAuthEnabled: async () => {
     try {
@@ -3,16 +3,6 @@ import apiClient from './apiClient';
 
 export const fieldService = {
   create: async (fieldData) => {
-    // Prevent creating boolean array fields
-    if (fieldData.type === 3 && fieldData.is_array) {
-      throw new Error('Boolean type fields cannot be arrays.');
-    }
-    
-    // Prevent creating datetime array fields
-    if (fieldData.type === 4 && fieldData.is_array) {
-      throw new Error('Datetime type fields cannot be arrays.');
-    }
-    
     try {
       const response = await apiClient.post('/field', fieldData);
       return response;
@@ -38,5 +28,15 @@ export const fieldService = {
       throw new Error(`Failed to restore field: ${error.message}`);
     }
   },
+  // 更新选择字段的选项，带 id 的选项保留已有的值
+  updateOptions: async (id, options) => {
+    try {
+      const response = await apiClient.put(`/field/${id}/options`, { options });
+      return response;
+    } catch (error) {
+      throw new Error(`Failed to update field options: ${error.message}`);
+    }
+  },
+
   // Add more methods for other field operations as needed (e.g., update if backend supports it)
 };
\ No newline at end of file
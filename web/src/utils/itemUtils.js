@@ -7,12 +7,15 @@ export const getFieldTypeName = (type) => {
     case 2: return 'Integer';
     case 3: return 'Boolean';
     case 4: return 'Datetime';
+    case 5: return 'Select';
+    case 6: return 'Decimal';
+    case 7: return 'Reference';
     default: return 'Unknown';
   }
 };
 
 // Format field value based on its type
-export const formatFieldValue = (value, fieldType) => {
+export const formatFieldValue = (value, fieldType, unit) => {
   if (value === null || value === undefined) {
     return 'N/A';
   }
@@ -36,6 +39,16 @@ export const formatFieldValue = (value, fieldType) => {
         return date.toLocaleString();
       }
       return 'Invalid Date Type';
+    case 5: // Select, value is the option name or a list of option names
+      return Array.isArray(value) ? value.join(', ') : String(value);
+    case 6: { // Decimal, with an optional unit label
+      const format = (v) => (unit ? `${v} ${unit}` : String(v));
+      return Array.isArray(value) ? value.map(format).join(', ') : format(value);
+    }
+    case 7: { // Reference, value is { id, name } or a list of them, null when the item is gone
+      const format = (v) => (v ? v.name : 'N/A');
+      return Array.isArray(value) ? value.map(format).join(', ') : format(value);
+    }
     default:
       return String(value); // Fallback
   }